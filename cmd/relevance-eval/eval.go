@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+)
+
+// evaluation holds the metrics of a judged query, computed on the concepts found with a ranking profile.
+type evaluation struct {
+	Query          judgedQuery
+	Ranked         []string
+	NDCG           float64
+	ReciprocalRank float64
+	Recall         float64
+}
+
+// evaluate runs every judged query through the search service with the given ranking profile.
+func evaluate(ctx context.Context, search service.ConceptSearchService, queries []judgedQuery, profile string) ([]evaluation, error) {
+	evaluations := []evaluation{}
+	for _, q := range queries {
+		opts := service.SearchOptions{Profile: profile}
+		var result service.SearchResult
+		var err error
+		if q.Boost != "" {
+			result, err = search.SearchConceptByTextAndTypesWithBoost(ctx, q.Query, q.Types, q.Boost, opts)
+		} else {
+			result, err = search.SearchConceptByTextAndTypes(ctx, q.Query, q.Types, opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("judged query %v: %v", q.label(), err)
+		}
+
+		ranked := []string{}
+		for _, c := range result.Concepts {
+			uuid, _ := util.ConceptUUID(c.Id)
+			ranked = append(ranked, uuid)
+		}
+		evaluations = append(evaluations, evaluation{
+			Query:          q,
+			Ranked:         ranked,
+			NDCG:           ndcg(ranked, q.Judgements, cutoff),
+			ReciprocalRank: reciprocalRank(ranked, q.Judgements),
+			Recall:         recall(ranked, q.Judgements, cutoff),
+		})
+	}
+	return evaluations, nil
+}
+
+// mean returns the mean evaluation of all the queries, i.e. MRR for the reciprocal rank
+func mean(evaluations []evaluation) evaluation {
+	m := evaluation{Query: judgedQuery{Query: "MEAN"}}
+	if len(evaluations) == 0 {
+		return m
+	}
+	for _, e := range evaluations {
+		m.NDCG += e.NDCG
+		m.ReciprocalRank += e.ReciprocalRank
+		m.Recall += e.Recall
+	}
+	n := float64(len(evaluations))
+	m.NDCG, m.ReciprocalRank, m.Recall = m.NDCG/n, m.ReciprocalRank/n, m.Recall/n
+	return m
+}
+
+func writeReport(w io.Writer, evaluations []evaluation) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "query\tndcg@%v\trr\trecall@%v\n", cutoff, cutoff)
+	for _, e := range append(evaluations, mean(evaluations)) {
+		fmt.Fprintf(tw, "%v\t%.3f\t%.3f\t%.3f\n", label(e), e.NDCG, e.ReciprocalRank, e.Recall)
+	}
+	return tw.Flush()
+}
+
+// writeDiff reports the metrics of the candidate ranking next to the baseline ones, and returns the number of
+// queries for which any of them went down by more than the tolerance.
+func writeDiff(w io.Writer, baseline []evaluation, candidate []evaluation, tolerance float64) (int, error) {
+	if len(baseline) != len(candidate) {
+		return 0, fmt.Errorf("cannot compare %v evaluations to %v", len(candidate), len(baseline))
+	}
+
+	regressions := 0
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "query\tndcg@%v\t\trr\t\trecall@%v\t\t\n", cutoff, cutoff)
+	baseline, candidate = append(baseline, mean(baseline)), append(candidate, mean(candidate))
+	for i := range candidate {
+		b, c := baseline[i], candidate[i]
+		regressed := c.NDCG < b.NDCG-tolerance || c.ReciprocalRank < b.ReciprocalRank-tolerance || c.Recall < b.Recall-tolerance
+		verdict := ""
+		if regressed {
+			verdict = "REGRESSION"
+			if i < len(candidate)-1 {
+				regressions++
+			}
+		}
+		fmt.Fprintf(tw, "%v\t%.3f -> %.3f\t%+.3f\t%.3f -> %.3f\t%+.3f\t%.3f -> %.3f\t%+.3f\t%v\n", label(c),
+			b.NDCG, c.NDCG, c.NDCG-b.NDCG,
+			b.ReciprocalRank, c.ReciprocalRank, c.ReciprocalRank-b.ReciprocalRank,
+			b.Recall, c.Recall, c.Recall-b.Recall,
+			verdict)
+	}
+	return regressions, tw.Flush()
+}
+
+func label(e evaluation) string {
+	if len(e.Query.Types) == 0 {
+		return e.Query.Query
+	}
+	return e.Query.label()
+}
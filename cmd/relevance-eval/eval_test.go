@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// stubSearchService returns the concepts of the given UUIDs for each query text, whatever the profile.
+type stubSearchService struct {
+	service.ConceptSearchService
+	results  map[string][]string
+	profiles []string
+}
+
+func (s *stubSearchService) SearchConceptByTextAndTypes(ctx context.Context, textQuery string, conceptTypes []string, opts service.SearchOptions) (service.SearchResult, error) {
+	s.profiles = append(s.profiles, opts.Profile)
+	if opts.Profile == "missing" {
+		return service.SearchResult{}, util.NewInputError("unknown ranking profile missing")
+	}
+	concepts := []service.Concept{}
+	for _, uuid := range s.results[textQuery] {
+		concepts = append(concepts, service.Concept{Id: "http://www.ft.com/thing/" + uuid})
+	}
+	return service.SearchResult{Concepts: concepts}, nil
+}
+
+func (s *stubSearchService) SearchConceptByTextAndTypesWithBoost(ctx context.Context, textQuery string, conceptTypes []string, boostType string, opts service.SearchOptions) (service.SearchResult, error) {
+	return s.SearchConceptByTextAndTypes(ctx, textQuery+" "+boostType, conceptTypes, opts)
+}
+
+const (
+	uuidA = "2a88a647-59bc-4043-8f1b-5add71ddb3a0"
+	uuidB = "61d707b5-6fab-3541-b017-49b72de80772"
+	uuidC = "40f636a3-5507-4311-9629-95376007cb7b"
+)
+
+var testQueries = []judgedQuery{
+	{Query: "lex", Types: []string{"http://www.ft.com/ontology/product/Brand"}, Judgements: map[string]int{uuidA: 3, uuidB: 1}},
+	{Query: "wolf", Types: []string{"http://www.ft.com/ontology/person/Person"}, Boost: "authors", Judgements: map[string]int{uuidC: 3}},
+}
+
+func TestLoadJudgedQueries(t *testing.T) {
+	queries, err := loadJudgedQueries("testdata/queries.yml")
+	require.NoError(t, err)
+	require.Len(t, queries, 9)
+	assert.Equal(t, "donald [Person]", queries[0].label())
+	assert.Equal(t, "martin [Person] boost=authors", queries[6].label())
+	assert.Equal(t, map[string]int{"cb01ce2e-f028-4fcc-8459-e02ac3fb8e8d": 3}, queries[4].Judgements, "judged concepts should be keyed by UUID")
+
+	corpus, err := loadCorpus("testdata/corpus.json")
+	require.NoError(t, err)
+	uuids := map[string]bool{}
+	for _, c := range corpus {
+		uuid, ok := util.ConceptUUID(c.Id)
+		require.True(t, ok, c.Id)
+		uuids[uuid] = true
+	}
+	for _, q := range queries {
+		for uuid := range q.Judgements {
+			assert.True(t, uuids[uuid], "judged concept %v of %v should be part of the corpus", uuid, q.label())
+		}
+	}
+}
+
+func TestLoadInvalidJudgedQueries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "relevance-eval")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name    string
+		queries string
+	}{
+		{"no queries", "queries: []\n"},
+		{"no types", "queries:\n  - q: lex\n    judgements: {" + uuidA + ": 1}\n"},
+		{"no relevant concept", "queries:\n  - q: lex\n    types: [x]\n    judgements: {" + uuidA + ": 0}\n"},
+		{"negative grade", "queries:\n  - q: lex\n    types: [x]\n    judgements: {" + uuidA + ": -1}\n"},
+		{"invalid id", "queries:\n  - q: lex\n    types: [x]\n    judgements: {lex: 1}\n"},
+		{"unknown field", "queries:\n  - q: lex\n    types: [x]\n    grades: {" + uuidA + ": 1}\n"},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(dir, "queries.yml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(tc.queries), 0600))
+		_, err := loadJudgedQueries(path)
+		assert.Error(t, err, tc.name)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	search := &stubSearchService{results: map[string][]string{
+		"lex":          {uuidB, uuidC, uuidA},
+		"wolf authors": {uuidC},
+	}}
+
+	evaluations, err := evaluate(context.Background(), search, testQueries, "popular")
+	require.NoError(t, err)
+	require.Len(t, evaluations, 2)
+	assert.Equal(t, []string{"popular", "popular"}, search.profiles)
+
+	assert.Equal(t, []string{uuidB, uuidC, uuidA}, evaluations[0].Ranked)
+	assert.InDelta(t, 1, evaluations[0].ReciprocalRank, 1e-9)
+	assert.InDelta(t, 1, evaluations[0].Recall, 1e-9)
+	assert.True(t, evaluations[0].NDCG < 1, "a swapped ranking is not ideal")
+	assert.InDelta(t, 1, evaluations[1].NDCG, 1e-9)
+
+	_, err = evaluate(context.Background(), search, testQueries, "missing")
+	assert.EqualError(t, err, "judged query lex [Brand]: unknown ranking profile missing")
+}
+
+func TestReport(t *testing.T) {
+	evaluations := []evaluation{
+		{Query: testQueries[0], NDCG: 0.5, ReciprocalRank: 1, Recall: 1},
+		{Query: testQueries[1], NDCG: 1, ReciprocalRank: 0.5, Recall: 0},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeReport(out, evaluations))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"query", "ndcg@10", "rr", "recall@10"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"lex", "[Brand]", "0.500", "1.000", "1.000"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"MEAN", "0.750", "0.750", "0.500"}, strings.Fields(lines[3]))
+}
+
+func TestDiff(t *testing.T) {
+	baseline := []evaluation{
+		{Query: testQueries[0], NDCG: 0.5, ReciprocalRank: 1, Recall: 1},
+		{Query: testQueries[1], NDCG: 1, ReciprocalRank: 1, Recall: 1},
+	}
+	candidate := []evaluation{
+		{Query: testQueries[0], NDCG: 0.8, ReciprocalRank: 1, Recall: 1},
+		{Query: testQueries[1], NDCG: 0.9995, ReciprocalRank: 0.5, Recall: 1},
+	}
+
+	out := &bytes.Buffer{}
+	regressions, err := writeDiff(out, baseline, candidate, 0.001)
+	require.NoError(t, err)
+	assert.Equal(t, 1, regressions)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.NotContains(t, lines[1], "REGRESSION")
+	assert.Contains(t, lines[1], "0.500 -> 0.800  +0.300")
+	assert.Contains(t, lines[2], "1.000 -> 0.500  -0.500")
+	assert.Contains(t, lines[2], "REGRESSION")
+
+	regressions, err = writeDiff(&bytes.Buffer{}, baseline, baseline, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, regressions, "an unchanged ranking should not regress")
+
+	_, err = writeDiff(&bytes.Buffer{}, baseline, candidate[:1], 0)
+	assert.Error(t, err)
+}
+
+func TestEvaluateCorpusWithElasticsearch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("ElasticSearch integration for long tests only.")
+	}
+	esURL := os.Getenv("ELASTICSEARCH_TEST_URL")
+	if strings.TrimSpace(esURL) == "" {
+		esURL = "http://localhost:9200"
+	}
+	ec, err := elastic.NewClient(elastic.SetURL(esURL), elastic.SetSniff(false))
+	require.NoError(t, err)
+
+	corpus, err := loadCorpus("testdata/corpus.json")
+	require.NoError(t, err)
+	queries, err := loadJudgedQueries("testdata/queries.yml")
+	require.NoError(t, err)
+
+	index := "relevance-eval-test"
+	require.NoError(t, indexCorpus(context.Background(), ec, index, "../../service/test/mapping.json", corpus))
+	defer ec.DeleteIndex(index).Do(context.Background())
+	assert.Error(t, indexCorpus(context.Background(), ec, index, "../../service/test/mapping.json", corpus), "an existing index should not be overwritten")
+
+	repository := service.NewEsConceptRepository(time.Minute)
+	repository.SetElasticClient(ec)
+	evaluations, err := evaluate(context.Background(), newSearchService(repository, service.NewRankingProfiles(), index), queries, "")
+	require.NoError(t, err)
+
+	for _, e := range evaluations {
+		assert.Equal(t, 1.0, e.ReciprocalRank, fmt.Sprintf("the top result of %v should be relevant", e.Query.label()))
+	}
+	assert.True(t, mean(evaluations).NDCG > 0.9, "the built-in profile should rank the fixture well")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+	"gopkg.in/olivere/elastic.v5"
+	"gopkg.in/yaml.v2"
+)
+
+// judgedQuery is a typeahead search along with the relevance grade of the concepts it should find, keyed by UUID.
+// Grades are 0 (not relevant) to 3 (the expected top result); the concepts which are not judged are not relevant.
+type judgedQuery struct {
+	Query      string         `yaml:"q"`
+	Types      []string       `yaml:"types"`
+	Boost      string         `yaml:"boost"`
+	Judgements map[string]int `yaml:"judgements"`
+}
+
+// label identifies the query in the reports, by its text and the short names of its types
+func (q judgedQuery) label() string {
+	types := []string{}
+	for _, t := range q.Types {
+		types = append(types, t[strings.LastIndex(t, "/")+1:])
+	}
+	label := fmt.Sprintf("%v [%v]", q.Query, strings.Join(types, ","))
+	if q.Boost != "" {
+		label += " boost=" + q.Boost
+	}
+	return label
+}
+
+type judgedQueries struct {
+	Queries []judgedQuery `yaml:"queries"`
+}
+
+// loadJudgedQueries reads the judged queries of a YAML or JSON file. The judged concepts can be given as UUIDs
+// or FT concept URIs.
+func loadJudgedQueries(path string) ([]judgedQuery, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := judgedQueries{}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid judged queries %v: %v", path, err)
+	}
+	if len(file.Queries) == 0 {
+		return nil, fmt.Errorf("no judged queries in %v", path)
+	}
+
+	for i, q := range file.Queries {
+		if q.Query == "" || len(q.Types) == 0 {
+			return nil, fmt.Errorf("judged query %v of %v should have both a q and types", i+1, path)
+		}
+		judgements := map[string]int{}
+		relevant := false
+		for id, grade := range q.Judgements {
+			uuid, ok := util.ConceptUUID(id)
+			if !ok {
+				return nil, fmt.Errorf("judged query %v: invalid concept id %v", q.label(), id)
+			}
+			if grade < 0 {
+				return nil, fmt.Errorf("judged query %v: negative grade for %v", q.label(), id)
+			}
+			judgements[uuid] = grade
+			relevant = relevant || grade > 0
+		}
+		if !relevant {
+			return nil, fmt.Errorf("judged query %v has no relevant concept", q.label())
+		}
+		file.Queries[i].Judgements = judgements
+	}
+	return file.Queries, nil
+}
+
+// loadCorpus reads the concepts of a JSON file, as they are stored in Elasticsearch.
+func loadCorpus(path string) ([]service.EsConceptModel, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	concepts := []service.EsConceptModel{}
+	if err := json.Unmarshal(data, &concepts); err != nil {
+		return nil, fmt.Errorf("invalid corpus %v: %v", path, err)
+	}
+	if len(concepts) == 0 {
+		return nil, fmt.Errorf("no concepts in %v", path)
+	}
+	return concepts, nil
+}
+
+// indexCorpus creates the given index, which should not exist yet, and writes the concepts in it under the ES type
+// of their direct type. The concepts can be searched as soon as it returns, and the index is deleted again when they
+// cannot be loaded.
+func indexCorpus(ctx context.Context, ec *elastic.Client, index string, mappingFile string, concepts []service.EsConceptModel) error {
+	exists, err := ec.IndexExists(index).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the index %v already exists, delete it or pick another one", index)
+	}
+
+	mapping, err := ioutil.ReadFile(mappingFile)
+	if err != nil {
+		return err
+	}
+	if _, err := ec.CreateIndex(index).BodyString(string(mapping)).Do(ctx); err != nil {
+		return err
+	}
+	if err := loadConcepts(ctx, ec, index, concepts); err != nil {
+		ec.DeleteIndex(index).Do(ctx) // so that the next run does not find it
+		return err
+	}
+	return nil
+}
+
+// loadConcepts bulk indexes the concepts, failing when any of them could not be indexed
+func loadConcepts(ctx context.Context, ec *elastic.Client, index string, concepts []service.EsConceptModel) error {
+	bulk := ec.Bulk().Index(index).Refresh("wait_for")
+	for _, concept := range concepts {
+		uuid, ok := util.ConceptUUID(concept.Id)
+		if !ok {
+			return fmt.Errorf("invalid concept id %v", concept.Id)
+		}
+		filter, err := util.NewConceptTypeFilter(concept.DirectType)
+		if err != nil {
+			return fmt.Errorf("concept %v: %v", concept.Id, err)
+		}
+		bulk.Add(elastic.NewBulkIndexRequest().Type(filter.EsType).Id(uuid).Doc(concept))
+	}
+	resp, err := bulk.Do(ctx)
+	if err != nil {
+		return err
+	}
+	if failed := resp.Failed(); len(failed) > 0 {
+		return fmt.Errorf("could not index %v concepts, e.g. %v: %v", len(failed), failed[0].Id, failed[0].Error.Reason)
+	}
+	return nil
+}
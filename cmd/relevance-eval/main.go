@@ -0,0 +1,193 @@
+// Command relevance-eval measures the ranking of the typeahead searches: it loads a fixture corpus into a local
+// Elasticsearch, runs judged queries through the concept search service and reports NDCG@10, the reciprocal rank
+// (MRR overall) and recall@10 for each of them. Given a baseline ranking configuration, it reports the differences
+// and fails when any query regressed.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/jawher/mow.cli"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func main() {
+	app := cli.App("relevance-eval", "Evaluate the ranking of the typeahead concept searches against judged queries")
+	esURL := app.String(cli.StringOpt{
+		Name:   "elasticsearch-url",
+		Value:  "http://localhost:9200",
+		Desc:   "The local Elasticsearch the corpus is loaded into",
+		EnvVar: "ELASTICSEARCH_URL",
+	})
+	index := app.String(cli.StringOpt{
+		Name:  "index",
+		Value: "relevance-eval",
+		Desc:  "The index holding the corpus, which should not exist beforehand",
+	})
+	keepIndex := app.Bool(cli.BoolOpt{
+		Name:  "keep-index",
+		Value: false,
+		Desc:  "Keep the index once evaluated, rather than deleting it",
+	})
+	mapping := app.String(cli.StringOpt{
+		Name:  "mapping",
+		Value: "service/test/mapping.json",
+		Desc:  "The mapping of the index",
+	})
+	corpus := app.String(cli.StringOpt{
+		Name:  "corpus",
+		Value: "cmd/relevance-eval/testdata/corpus.json",
+		Desc:  "The JSON file holding the concepts to search, as stored in Elasticsearch",
+	})
+	queries := app.String(cli.StringOpt{
+		Name:  "queries",
+		Value: "cmd/relevance-eval/testdata/queries.yml",
+		Desc:  "The YAML or JSON file holding the judged queries",
+	})
+	rankingConfig := app.String(cli.StringOpt{
+		Name:  "ranking-config",
+		Value: "",
+		Desc:  "The ranking configuration to evaluate, the built-in profile is used when empty",
+	})
+	profile := app.String(cli.StringOpt{
+		Name:  "profile",
+		Value: "",
+		Desc:  "The ranking profile to evaluate, the default one of the ranking-config when empty",
+	})
+	baselineRankingConfig := app.String(cli.StringOpt{
+		Name:  "baseline-ranking-config",
+		Value: "",
+		Desc:  "The ranking configuration to compare to, the built-in profile is used when empty",
+	})
+	baselineProfile := app.String(cli.StringOpt{
+		Name:  "baseline-profile",
+		Value: "",
+		Desc:  "The ranking profile to compare to, the default one of the baseline-ranking-config when empty",
+	})
+	compare := app.Bool(cli.BoolOpt{
+		Name:  "compare",
+		Value: false,
+		Desc:  "Compare the ranking to the baseline one, which is implied by any of the baseline options",
+	})
+	tolerance := app.String(cli.StringOpt{
+		Name:  "tolerance",
+		Value: "0.001",
+		Desc:  "How much a metric can go down before a query is reported as a regression",
+	})
+
+	app.Action = func() {
+		maxDrop, err := strconv.ParseFloat(*tolerance, 64)
+		if err != nil || maxDrop < 0 {
+			log.WithError(err).Fatalf("Invalid tolerance %v", *tolerance)
+		}
+		judged, err := loadJudgedQueries(*queries)
+		if err != nil {
+			log.WithError(err).Fatal("Could not load the judged queries")
+		}
+		concepts, err := loadCorpus(*corpus)
+		if err != nil {
+			log.WithError(err).Fatal("Could not load the corpus")
+		}
+		candidateRanking := loadRankingProfiles(*rankingConfig)
+
+		var baselineRanking *service.RankingProfiles
+		if *compare || *baselineRankingConfig != "" || *baselineProfile != "" {
+			baselineRanking = loadRankingProfiles(*baselineRankingConfig)
+		}
+
+		ec, err := elastic.NewClient(elastic.SetURL(*esURL), elastic.SetSniff(false))
+		if err != nil {
+			log.WithError(err).Fatalf("Could not connect to Elasticsearch at %v", *esURL)
+		}
+		run := evaluationRun{
+			index:           *index,
+			keepIndex:       *keepIndex,
+			mapping:         *mapping,
+			concepts:        concepts,
+			judged:          judged,
+			ranking:         candidateRanking,
+			profile:         *profile,
+			baselineRanking: baselineRanking,
+			baselineProfile: *baselineProfile,
+			tolerance:       maxDrop,
+		}
+		regressions, err := run.evaluate(context.Background(), ec)
+		if err != nil {
+			log.WithError(err).Fatal("Could not evaluate the ranking")
+		}
+		if regressions > 0 {
+			log.Errorf("the ranking regressed for %v of %v judged queries", regressions, len(judged))
+			cli.Exit(1)
+		}
+	}
+
+	log.SetLevel(log.InfoLevel)
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Errorf("relevance-eval could not start, error=[%s]\n", err)
+		return
+	}
+}
+
+// evaluationRun holds what is evaluated: the ranking, and the baseline one it is compared to when there is any
+type evaluationRun struct {
+	index           string
+	keepIndex       bool
+	mapping         string
+	concepts        []service.EsConceptModel
+	judged          []judgedQuery
+	ranking         *service.RankingProfiles
+	profile         string
+	baselineRanking *service.RankingProfiles
+	baselineProfile string
+	tolerance       float64
+}
+
+// evaluate loads the corpus into the index, reports how the ranking performs and returns the number of judged queries
+// for which it regressed. The index is deleted once evaluated, whether the evaluation succeeded or not, unless kept.
+func (r evaluationRun) evaluate(ctx context.Context, ec *elastic.Client) (int, error) {
+	if err := indexCorpus(ctx, ec, r.index, r.mapping, r.concepts); err != nil {
+		return 0, fmt.Errorf("could not load the corpus into Elasticsearch: %v", err)
+	}
+	if !r.keepIndex {
+		defer ec.DeleteIndex(r.index).Do(ctx)
+	}
+	log.Infof("loaded %v concepts into %v, evaluating %v judged queries", len(r.concepts), r.index, len(r.judged))
+
+	repository := service.NewEsConceptRepository(time.Minute)
+	repository.SetElasticClient(ec)
+	candidate, err := evaluate(ctx, newSearchService(repository, r.ranking, r.index), r.judged, r.profile)
+	if err != nil {
+		return 0, err
+	}
+	if r.baselineRanking == nil {
+		return 0, writeReport(os.Stdout, candidate)
+	}
+
+	baseline, err := evaluate(ctx, newSearchService(repository, r.baselineRanking, r.index), r.judged, r.baselineProfile)
+	if err != nil {
+		return 0, fmt.Errorf("could not evaluate the baseline ranking: %v", err)
+	}
+	return writeDiff(os.Stdout, baseline, candidate, r.tolerance)
+}
+
+func loadRankingProfiles(path string) *service.RankingProfiles {
+	if path == "" {
+		return service.NewRankingProfiles()
+	}
+	profiles, err := service.LoadRankingProfiles(path)
+	if err != nil {
+		log.WithError(err).Fatalf("Invalid ranking configuration %v", path)
+	}
+	return profiles
+}
+
+func newSearchService(repository service.ConceptRepository, ranking *service.RankingProfiles, index string) service.ConceptSearchService {
+	return service.NewRankedConceptSearchService(repository, ranking, index, "", cutoff, cutoff)
+}
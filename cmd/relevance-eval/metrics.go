@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// cutoff is the number of results the metrics are computed on, i.e. the length of a typeahead list
+const cutoff = 10
+
+// ndcg returns the normalised discounted cumulative gain of the first k ranked concepts, using graded relevance:
+// 1 for the ideal ranking of the judged concepts, 0 when none of the relevant ones has been found.
+func ndcg(ranked []string, judgements map[string]int, k int) float64 {
+	grades := []int{}
+	for _, grade := range judgements {
+		grades = append(grades, grade)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(grades)))
+
+	ideal := dcg(grades, k)
+	if ideal == 0 {
+		return 0
+	}
+
+	rankedGrades := []int{}
+	for _, uuid := range ranked {
+		rankedGrades = append(rankedGrades, judgements[uuid])
+	}
+	return dcg(rankedGrades, k) / ideal
+}
+
+func dcg(grades []int, k int) float64 {
+	sum := 0.0
+	for i, grade := range grades {
+		if i >= k {
+			break
+		}
+		sum += (math.Pow(2, float64(grade)) - 1) / math.Log2(float64(i+2))
+	}
+	return sum
+}
+
+// reciprocalRank returns 1/rank of the first relevant concept, 0 when none has been found.
+func reciprocalRank(ranked []string, judgements map[string]int) float64 {
+	for i, uuid := range ranked {
+		if judgements[uuid] > 0 {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// recall returns the share of the relevant concepts found in the first k ranked ones.
+func recall(ranked []string, judgements map[string]int, k int) float64 {
+	relevant := 0
+	for _, grade := range judgements {
+		if grade > 0 {
+			relevant++
+		}
+	}
+	if relevant == 0 {
+		return 0
+	}
+
+	found := 0
+	for i, uuid := range ranked {
+		if i >= k {
+			break
+		}
+		if judgements[uuid] > 0 {
+			found++
+		}
+	}
+	return float64(found) / float64(relevant)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testJudgements = map[string]int{"a": 3, "b": 2, "c": 1, "d": 0}
+
+func TestNDCG(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ranked   []string
+		k        int
+		expected float64
+	}{
+		{"ideal ranking", []string{"a", "b", "c"}, 10, 1},
+		{"ideal ranking with irrelevant results", []string{"a", "b", "c", "d", "e"}, 10, 1},
+		{"nothing relevant", []string{"d", "e"}, 10, 0},
+		{"no results", []string{}, 10, 0},
+		{"swapped", []string{"b", "a", "c"}, 10, (3 + 7/math.Log2(3) + 1/2.0) / (7 + 3/math.Log2(3) + 1/2.0)},
+		{"relevant beyond k", []string{"d", "a"}, 1, 0},
+		{"only the top k count", []string{"a", "c", "b"}, 1, 1},
+	}
+
+	for _, tc := range testCases {
+		assert.InDelta(t, tc.expected, ndcg(tc.ranked, testJudgements, tc.k), 1e-9, tc.name)
+	}
+}
+
+func TestReciprocalRank(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ranked   []string
+		expected float64
+	}{
+		{"first", []string{"c", "a"}, 1},
+		{"third", []string{"d", "e", "b"}, 1.0 / 3},
+		{"nothing relevant", []string{"d", "e"}, 0},
+	}
+
+	for _, tc := range testCases {
+		assert.InDelta(t, tc.expected, reciprocalRank(tc.ranked, testJudgements), 1e-9, tc.name)
+	}
+}
+
+func TestRecall(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ranked   []string
+		k        int
+		expected float64
+	}{
+		{"all found", []string{"c", "b", "a"}, 10, 1},
+		{"irrelevant results do not count", []string{"a", "d"}, 10, 1.0 / 3},
+		{"only the top k count", []string{"a", "b", "c"}, 2, 2.0 / 3},
+		{"nothing found", []string{}, 10, 0},
+	}
+
+	for _, tc := range testCases {
+		assert.InDelta(t, tc.expected, recall(tc.ranked, testJudgements, tc.k), 1e-9, tc.name)
+	}
+}
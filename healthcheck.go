@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"sync"
 
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
 	"github.com/Financial-Times/service-status-go/gtg"
 	"github.com/pkg/errors"
@@ -18,17 +20,16 @@ const (
 )
 
 type esHealthService struct {
-	client     esClient
-	clientLock *sync.RWMutex
+	repository service.ConceptRepository
 }
 
-func (service *esHealthService) getClusterHealth() (*elastic.ClusterHealthResponse, error) {
-	return service.esClient().getClusterHealth()
+func (service *esHealthService) getClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error) {
+	return service.repository.ClusterHealth(ctx)
 }
 
-func newEsHealthService() *esHealthService {
+func newEsHealthService(repository service.ConceptRepository) *esHealthService {
 	return &esHealthService{
-		clientLock: &sync.RWMutex{},
+		repository: repository,
 	}
 }
 
@@ -45,17 +46,15 @@ func (service *esHealthService) clusterIsHealthyCheck() fthealth.Check {
 }
 
 func (service *esHealthService) healthChecker() (string, error) {
-	if service.esClient() != nil {
-		output, err := service.getClusterHealth()
-		if err != nil {
-			return "Cluster is not healthy: ", err
-		} else if output.Status != "green" {
-			return fmt.Sprintf("Cluster is %v", output.Status), fmt.Errorf("Cluster is %v", output.Status)
-		}
-		return "Cluster is healthy", nil
+	output, err := service.getClusterHealth(context.Background())
+	if err == util.ErrNoElasticClient {
+		return "Couldn't check the cluster's health", errors.New("Couldn't establish connectivity")
+	} else if err != nil {
+		return "Cluster is not healthy: ", err
+	} else if output.Status != "green" {
+		return fmt.Sprintf("Cluster is %v", output.Status), fmt.Errorf("Cluster is %v", output.Status)
 	}
-
-	return "Couldn't check the cluster's health", errors.New("Couldn't establish connectivity")
+	return "Cluster is healthy", nil
 }
 
 func (service *esHealthService) connectivityHealthyCheck() fthealth.Check {
@@ -71,11 +70,10 @@ func (service *esHealthService) connectivityHealthyCheck() fthealth.Check {
 }
 
 func (service *esHealthService) connectivityChecker() (string, error) {
-	if service.esClient() == nil {
+	_, err := service.getClusterHealth(context.Background())
+	if err == util.ErrNoElasticClient {
 		return "", errors.New("Could not connect to elasticsearch, please check the application parameters/env variables, and restart the service")
 	}
-
-	_, err := service.getClusterHealth()
 	if err != nil {
 		return "Could not connect to elasticsearch", err
 	}
@@ -101,12 +99,7 @@ func gtgCheck(handler func() (string, error)) gtg.Status {
 func (service *esHealthService) healthDetails(writer http.ResponseWriter, req *http.Request) {
 	writer.Header().Set("Content-Type", "application/json")
 
-	if writer == nil || service.esClient() == nil {
-		writer.WriteHeader(http.StatusServiceUnavailable)
-		return
-	}
-
-	output, err := service.getClusterHealth()
+	output, err := service.getClusterHealth(req.Context())
 	if err != nil {
 		writer.WriteHeader(http.StatusServiceUnavailable)
 		return
@@ -123,15 +116,3 @@ func (service *esHealthService) healthDetails(writer http.ResponseWriter, req *h
 		log.Errorf(err.Error())
 	}
 }
-
-func (service *esHealthService) SetElasticClient(client *elastic.Client) {
-	service.clientLock.Lock()
-	defer service.clientLock.Unlock()
-	service.client = &esClientWrapper{elasticClient: client}
-}
-
-func (service *esHealthService) esClient() esClient {
-	service.clientLock.RLock()
-	defer service.clientLock.RUnlock()
-	return service.client
-}
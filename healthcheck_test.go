@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Financial-Times/concept-search-api/service"
 	status "github.com/Financial-Times/service-status-go/httphandlers"
 	"github.com/stretchr/testify/assert"
 
@@ -23,8 +25,8 @@ func TestHealthDetailsHealthyCluster(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	healthService := newEsHealthService()
-	healthService.client = hcClient{healthy: true}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{healthy: true}
 
 	//create a responseRecorder
 	rr := httptest.NewRecorder()
@@ -62,8 +64,8 @@ func TestHealthDetailsReturnsError(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	healthService := newEsHealthService()
-	healthService.client = hcClient{returnError: errors.New("test error")}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{returnError: errors.New("test error")}
 
 	//create a responseRecorder
 	rr := httptest.NewRecorder()
@@ -93,8 +95,8 @@ func TestGTGUnhealthyCluster(t *testing.T) {
 	//create a request to pass to our handler
 	req := httptest.NewRequest("GET", "/__gtg", nil)
 
-	healthService := newEsHealthService()
-	healthService.client = hcClient{returnError: errors.New("test error")}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{returnError: errors.New("test error")}
 	//create a responseRecorder
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(status.NewGoodToGoHandler(healthService.GTG))
@@ -113,8 +115,8 @@ func TestGTGUnhealthyCluster(t *testing.T) {
 func TestGTGHealthyCluster(t *testing.T) {
 	//create a request to pass to our handler
 	req := httptest.NewRequest("GET", "/__gtg", nil)
-	healthService := newEsHealthService()
-	healthService.client = hcClient{healthy: true}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{healthy: true}
 	//create a responseRecorder
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(status.NewGoodToGoHandler(healthService.GTG))
@@ -131,8 +133,8 @@ func TestGTGHealthyCluster(t *testing.T) {
 }
 
 func TestHealthServiceConnectivityChecker(t *testing.T) {
-	healthService := newEsHealthService()
-	healthService.client = hcClient{healthy: true}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{healthy: true}
 	hc := healthService.connectivityHealthyCheck()
 
 	assert.Equal(t, "elasticsearch-connectivity", hc.ID, "healthcheck id")
@@ -144,8 +146,8 @@ func TestHealthServiceConnectivityChecker(t *testing.T) {
 }
 
 func TestHealthServiceConnectivityCheckerForFailedConnection(t *testing.T) {
-	healthService := newEsHealthService()
-	healthService.client = hcClient{returnError: errors.New("test error")}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{returnError: errors.New("test error")}
 	message, err := healthService.connectivityChecker()
 
 	assert.Equal(t, "Could not connect to elasticsearch", message)
@@ -153,7 +155,7 @@ func TestHealthServiceConnectivityCheckerForFailedConnection(t *testing.T) {
 }
 
 func TestHealthServiceConnectivityCheckerNilClient(t *testing.T) {
-	healthService := newEsHealthService()
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
 
 	_, err := healthService.connectivityChecker()
 
@@ -162,7 +164,7 @@ func TestHealthServiceConnectivityCheckerNilClient(t *testing.T) {
 }
 
 func TestHealthServiceHealthCheckerNilClient(t *testing.T) {
-	healthService := newEsHealthService()
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
 
 	_, err := healthService.healthChecker()
 
@@ -171,8 +173,8 @@ func TestHealthServiceHealthCheckerNilClient(t *testing.T) {
 }
 
 func TestHealthServiceHealthCheckerNotHealthyClient(t *testing.T) {
-	healthService := newEsHealthService()
-	healthService.client = hcClient{healthy: false}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{healthy: false}
 
 	message, err := healthService.healthChecker()
 
@@ -187,7 +189,7 @@ func TestHealthDetailsNilClient(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	healthService := newEsHealthService()
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
 
 	//create a responseRecorder
 	rr := httptest.NewRecorder()
@@ -214,8 +216,8 @@ func TestHealthDetailsNilClient(t *testing.T) {
 }
 
 func TestClusterIsHealthyChecker(t *testing.T) {
-	healthService := newEsHealthService()
-	healthService.client = hcClient{healthy: true}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{healthy: true}
 	hc := healthService.clusterIsHealthyCheck()
 
 	assert.Equal(t, "elasticsearch-cluster-health", hc.ID, "healthcheck id")
@@ -227,9 +229,9 @@ func TestClusterIsHealthyChecker(t *testing.T) {
 }
 
 func TestClusterIsHealthyCheckerError(t *testing.T) {
-	healthService := newEsHealthService()
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
 	expectedError := errors.New("test error")
-	healthService.client = hcClient{healthy: false, returnError: expectedError}
+	healthService.repository = hcClient{healthy: false, returnError: expectedError}
 	hc := healthService.clusterIsHealthyCheck()
 
 	assert.Equal(t, "elasticsearch-cluster-health", hc.ID, "healthcheck id")
@@ -241,8 +243,8 @@ func TestClusterIsHealthyCheckerError(t *testing.T) {
 }
 
 func TestClusterIsHealthyCheckerNotHealthy(t *testing.T) {
-	healthService := newEsHealthService()
-	healthService.client = hcClient{healthy: false}
+	healthService := newEsHealthService(service.NewEsConceptRepository(0))
+	healthService.repository = hcClient{healthy: false}
 	hc := healthService.clusterIsHealthyCheck()
 
 	assert.Equal(t, "elasticsearch-cluster-health", hc.ID, "healthcheck id")
@@ -258,15 +260,25 @@ type hcClient struct {
 	returnError error
 }
 
-func (c hcClient) query(indexName string, query elastic.Query, resultLimit int) (*elastic.SearchResult, error) {
+func (c hcClient) SetElasticClient(client *elastic.Client) {}
+
+func (c hcClient) Search(ctx context.Context, query service.SearchQuery) (*elastic.SearchResult, error) {
 	return &elastic.SearchResult{}, nil
 }
 
-func (c hcClient) multiSearchQuery(indexName string, searchRequests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
+func (c hcClient) MultiSearch(ctx context.Context, index string, requests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
 	return &elastic.MultiSearchResult{}, nil
 }
 
-func (c hcClient) getClusterHealth() (*elastic.ClusterHealthResponse, error) {
+func (c hcClient) Scroll(ctx context.Context, query service.SearchQuery, handle func(*elastic.SearchResult) error) error {
+	return nil
+}
+
+func (c hcClient) MultiGet(ctx context.Context, index string, ids []string) (*elastic.MgetResponse, error) {
+	return &elastic.MgetResponse{}, nil
+}
+
+func (c hcClient) ClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error) {
 	if c.returnError != nil {
 		return nil, c.returnError
 	}
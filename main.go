@@ -77,12 +77,66 @@ func main() {
 		Desc:   "The maximum number of autocomplete results returned",
 		EnvVar: "AUTOCOMPLETE_LIMIT",
 	})
-	authorsBoost := app.Int(cli.IntOpt{
+	app.Int(cli.IntOpt{ // kept so that existing deployments still start
 		Name:   "authors-boost",
 		Value:  10,
-		Desc:   "The boost to apply to authors during a /concepts?boost=author typeahead search.",
+		Desc:   "Deprecated and ignored, the boost of the authors is part of the ranking profiles (see ranking-config).",
 		EnvVar: "AUTHORS_BOOST",
 	})
+	esQueryTimeout := app.String(cli.StringOpt{
+		Name:   "es-query-timeout",
+		Value:  "10s",
+		Desc:   "How long to wait for each Elasticsearch query before giving up on it, e.g. 500ms or 5s",
+		EnvVar: "ES_QUERY_TIMEOUT",
+	})
+	cacheSize := app.Int(cli.IntOpt{
+		Name:   "cache-size",
+		Value:  0,
+		Desc:   "The maximum number of typeahead searches and type listings whose results are cached, 0 disables the cache",
+		EnvVar: "CACHE_SIZE",
+	})
+	cacheTTL := app.String(cli.StringOpt{
+		Name:   "cache-ttl",
+		Value:  "1m",
+		Desc:   "How long the cached results are served for, e.g. 30s or 5m",
+		EnvVar: "CACHE_TTL",
+	})
+	listingMaxAge := app.String(cli.StringOpt{
+		Name:   "listing-max-age",
+		Value:  "5m",
+		Desc:   "The Cache-Control max-age of the concept listings by type, 0 to make clients revalidate every time",
+		EnvVar: "LISTING_MAX_AGE",
+	})
+	searchMaxAge := app.String(cli.StringOpt{
+		Name:   "search-max-age",
+		Value:  "30s",
+		Desc:   "The Cache-Control max-age of the concept searches (mode=search), 0 to make clients revalidate every time",
+		EnvVar: "SEARCH_MAX_AGE",
+	})
+	idsMaxAge := app.String(cli.StringOpt{
+		Name:   "ids-max-age",
+		Value:  "5m",
+		Desc:   "The Cache-Control max-age of the concept lookups by id, 0 to make clients revalidate every time",
+		EnvVar: "IDS_MAX_AGE",
+	})
+	rankingConfig := app.String(cli.StringOpt{
+		Name:   "ranking-config",
+		Value:  "",
+		Desc:   "The YAML or JSON file holding the ranking profiles of the typeahead searches, the built-in profile is used when empty",
+		EnvVar: "RANKING_CONFIG",
+	})
+	rankingConfigReloadInterval := app.String(cli.StringOpt{
+		Name:   "ranking-config-reload-interval",
+		Value:  "30s",
+		Desc:   "How often to check the ranking-config file for changes, which are then applied without a restart",
+		EnvVar: "RANKING_CONFIG_RELOAD_INTERVAL",
+	})
+	explainEnabled := app.Bool(cli.BoolOpt{
+		Name:   "explain-enabled",
+		Value:  false,
+		Desc:   "Whether the scores of the typeahead searches can be explained, with explain=true",
+		EnvVar: "EXPLAIN_ENABLED",
+	})
 	esTraceLogging := app.Bool(cli.BoolOpt{
 		Name:   "elasticsearch-trace",
 		Value:  false,
@@ -93,20 +147,57 @@ func main() {
 	log.SetLevel(log.InfoLevel)
 
 	app.Action = func() {
-		logStartupConfig(port, esEndpoint, esAuth, esDefaultIndex, esExtendedSearchIndex, searchResultLimit)
+		logStartupConfig(port, esEndpoint, esAuth, esDefaultIndex, esExtendedSearchIndex, searchResultLimit, esQueryTimeout)
 
-		search := service.NewEsConceptSearchService(*esDefaultIndex, *esExtendedSearchIndex, *searchResultLimit, *autoCompleteResultLimit, *authorsBoost)
-		conceptFinder := newConceptFinder(*esDefaultIndex, *esExtendedSearchIndex, *searchResultLimit)
-		healthcheck := newEsHealthService()
+		queryTimeout, err := time.ParseDuration(*esQueryTimeout)
+		if err != nil {
+			log.WithError(err).Fatalf("Invalid es-query-timeout %v", *esQueryTimeout)
+		}
+
+		repository := service.NewEsConceptRepository(queryTimeout)
+		ranking := service.NewRankingProfiles()
+		if *rankingConfig != "" {
+			ranking, err = service.LoadRankingProfiles(*rankingConfig)
+			if err != nil {
+				log.WithError(err).Fatalf("Invalid ranking-config %v", *rankingConfig)
+			}
+			reloadInterval, err := time.ParseDuration(*rankingConfigReloadInterval)
+			if err != nil || reloadInterval <= 0 {
+				log.WithError(err).Fatalf("Invalid ranking-config-reload-interval %v", *rankingConfigReloadInterval)
+			}
+			go ranking.Watch(reloadInterval, nil)
+		}
+		profiles, defaultProfile := ranking.Names()
+		log.Infof("ranking profiles: %v, using %v by default", profiles, defaultProfile)
+
+		search := service.NewRankedConceptSearchService(repository, ranking, *esDefaultIndex, *esExtendedSearchIndex, *searchResultLimit, *autoCompleteResultLimit)
+		cacheHandler := resources.NewCacheHandler(nil)
+		if *cacheSize > 0 {
+			ttl, err := time.ParseDuration(*cacheTTL)
+			if err != nil {
+				log.WithError(err).Fatalf("Invalid cache-ttl %v", *cacheTTL)
+			}
+			log.Infof("caching the results of up to %v searches for %v", *cacheSize, ttl)
+			cache := service.NewCachingConceptSearchService(search, *cacheSize, ttl, metrics.DefaultRegistry)
+			cacheHandler = resources.NewCacheHandler(cache)
+			ranking.OnReload(cache.Purge) // the cached searches were ranked with the previous profiles
+			search = cache
+		}
+		healthcheck := newEsHealthService(repository)
 
 		if *esAuth == "aws" {
-			go service.AWSClientSetup(*accessKey, *secretKey, *esEndpoint, *esTraceLogging, time.Minute, search, conceptFinder, healthcheck)
+			go service.AWSClientSetup(*accessKey, *secretKey, *esEndpoint, *esTraceLogging, time.Minute, repository)
 		} else {
-			go service.SimpleClientSetup(*esEndpoint, *esTraceLogging, time.Minute, search, conceptFinder, healthcheck)
+			go service.SimpleClientSetup(*esEndpoint, *esTraceLogging, time.Minute, repository)
 		}
 
-		handler := resources.NewHandler(search)
-		routeRequest(port, apiYml, conceptFinder, handler, healthcheck)
+		maxAges := resources.CacheMaxAges{
+			Listing: parseMaxAge("listing-max-age", *listingMaxAge),
+			Search:  parseMaxAge("search-max-age", *searchMaxAge),
+			Ids:     parseMaxAge("ids-max-age", *idsMaxAge),
+		}
+		handler := resources.NewHandler(search, maxAges, *explainEnabled)
+		routeRequest(port, apiYml, handler, cacheHandler, healthcheck)
 	}
 
 	log.SetLevel(log.InfoLevel)
@@ -117,7 +208,15 @@ func main() {
 	}
 }
 
-func logStartupConfig(port, esEndpoint, esAuth, esDefaultIndex *string, esExtendedSearchIndex *string, searchResultLimit *int) {
+func parseMaxAge(name string, value string) time.Duration {
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge < 0 {
+		log.WithError(err).Fatalf("Invalid %v %v", name, value)
+	}
+	return maxAge
+}
+
+func logStartupConfig(port, esEndpoint, esAuth, esDefaultIndex *string, esExtendedSearchIndex *string, searchResultLimit *int, esQueryTimeout *string) {
 	log.Info("Concept Search API uses the following configurations:")
 	log.Infof("port: %v", *port)
 	log.Infof("elasticsearch-endpoint: %v", *esEndpoint)
@@ -125,12 +224,18 @@ func logStartupConfig(port, esEndpoint, esAuth, esDefaultIndex *string, esExtend
 	log.Infof("elasticsearch-index: %v", *esDefaultIndex)
 	log.Infof("elasticsearch-extended-index: %v", *esExtendedSearchIndex)
 	log.Infof("search-result-limit: %v", *searchResultLimit)
+	log.Infof("es-query-timeout: %v", *esQueryTimeout)
 }
 
-func routeRequest(port *string, apiYml *string, conceptFinder conceptFinder, handler *resources.Handler, healthService *esHealthService) {
+func routeRequest(port *string, apiYml *string, handler *resources.Handler, cacheHandler *resources.CacheHandler, healthService *esHealthService) {
 	servicesRouter := vestigo.NewRouter()
-	servicesRouter.Post("/concept/search", conceptFinder.FindConcept)
+	servicesRouter.Post("/concept/search", handler.SearchConceptsByTerm)
+	servicesRouter.Post("/v2/concepts/search", handler.SearchConceptsV2)
 	servicesRouter.Get("/concepts", handler.ConceptSearch, resources.AcceptInterceptor)
+	servicesRouter.Get("/concepts/export", handler.ExportConcepts)
+	servicesRouter.Post("/concepts/lookup", handler.LookupConcepts)
+	servicesRouter.Get("/concepts/:uuid", handler.GetConcept, resources.AcceptInterceptor)
+	servicesRouter.Post("/__cache/purge", cacheHandler.PurgeCache)
 
 	if apiYml != nil {
 		apiEndpoint, err := api.NewAPIEndpointForFile(*apiYml)
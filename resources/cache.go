@@ -0,0 +1,30 @@
+package resources
+
+import (
+	"net/http"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// CacheHandler serves the administration endpoints of the search results cache.
+type CacheHandler struct {
+	cache service.CachingConceptSearchService
+}
+
+// NewCacheHandler returns the handler of the cache administration endpoints, given no cache when caching is disabled
+func NewCacheHandler(cache service.CachingConceptSearchService) *CacheHandler {
+	return &CacheHandler{cache}
+}
+
+// PurgeCache empties the cache, so that the following searches and listings are answered from Elasticsearch.
+// There is nothing to purge when caching is disabled, which is answered the same way.
+func (h *CacheHandler) PurgeCache(w http.ResponseWriter, req *http.Request) {
+	if h.cache == nil {
+		log.Info("the search results cache is disabled, there is nothing to purge")
+	} else {
+		h.cache.Purge()
+		log.Info("the search results cache has been purged")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
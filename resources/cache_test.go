@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCachingConceptSearchService struct {
+	mockConceptSearchService
+	purged int
+}
+
+func (s *mockCachingConceptSearchService) Purge() {
+	s.purged++
+}
+
+func TestPurgeCache(t *testing.T) {
+	var cache service.CachingConceptSearchService = &mockCachingConceptSearchService{}
+	handler := NewCacheHandler(cache)
+
+	req := httptest.NewRequest("POST", "/__cache/purge", nil)
+	w := httptest.NewRecorder()
+	handler.PurgeCache(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code, "http status")
+	assert.Equal(t, 1, cache.(*mockCachingConceptSearchService).purged, "the cache should have been purged")
+}
+
+func TestPurgeDisabledCache(t *testing.T) {
+	handler := NewCacheHandler(nil)
+
+	req := httptest.NewRequest("POST", "/__cache/purge", nil)
+	w := httptest.NewRecorder()
+	handler.PurgeCache(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code, "purging a disabled cache should succeed")
+}
@@ -0,0 +1,74 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Financial-Times/concept-search-api/util"
+)
+
+// conceptFields are the fields of the concepts of GET /concepts and POST /v2/concepts/search which can be picked with
+// the fields parameter
+var conceptFields = []string{"id", "apiUrl", "prefLabel", "type", "aliases", "scopeNote", "countryCode", "countryOfIncorporation", "isFTAuthor", "isDeprecated", "lastModified", "publishReference", "metrics", "score"}
+
+// termSearchFields are the fields of the concepts of POST /concept/search which can be picked with the fields parameter
+var termSearchFields = []string{"id", "apiUrl", "prefLabel", "types", "directType", "aliases", "scopeNote", "countryCode", "countryOfIncorporation", "isFTAuthor", "isDeprecated", "lastModified", "publishReference", "metrics", "score"}
+
+// getFieldsParameter reads the fields query parameter, given either as comma separated or as repeated values
+func getFieldsParameter(req *http.Request, validFields []string) ([]string, bool, error) {
+	values, found := util.GetMultipleValueQueryParameter(req, "fields")
+	var fields []string
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+	}
+	if found && len(fields) == 0 {
+		return nil, found, newParameterError(util.ErrCodeInvalidParameter, "fields", "empty fields parameter")
+	}
+	return fields, found, validateFields(fields, validFields)
+}
+
+// validateFields checks that every field is one of the valid ones
+func validateFields(fields []string, validFields []string) error {
+	for _, field := range fields {
+		if !contains(validFields, field) {
+			return newParameterError(util.ErrCodeInvalidParameter, "fields", fmt.Sprintf("unknown concept field '%s', it should be one of %s", field, strings.Join(validFields, ", ")))
+		}
+	}
+	return nil
+}
+
+// pickFields encodes a concept with only the given fields (all of them when none are given), along with its highlight
+// and explanation, which are only there when asked for
+func pickFields(concept interface{}, fields []string) (json.RawMessage, error) {
+	encoded, err := json.Marshal(concept)
+	if err != nil || len(fields) == 0 {
+		return encoded, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+	picked := make(map[string]json.RawMessage)
+	for name, value := range all {
+		if contains(fields, name) || name == "highlight" || name == "explanation" {
+			picked[name] = value
+		}
+	}
+	return json.Marshal(picked)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
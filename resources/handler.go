@@ -1,134 +1,409 @@
 package resources
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Financial-Times/concept-search-api/util"
 
 	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/husobee/vestigo"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/olivere/elastic.v5"
 	"strings"
 )
 
 type Handler struct {
-	service service.ConceptSearchService
+	service        service.ConceptSearchService
+	maxAges        CacheMaxAges
+	explainEnabled bool
+}
+
+// CacheMaxAges holds the max-age of the Cache-Control header of the concept search responses, for each kind of search.
+// A zero max-age makes the clients revalidate the response (using its ETag) on every request.
+type CacheMaxAges struct {
+	Listing time.Duration
+	Search  time.Duration
+	Ids     time.Duration
 }
 
 type validationError struct {
-	msg string
+	msg   string
+	code  string
+	param string
 }
 
 func NewValidationError(msg string) validationError {
-	return validationError{msg}
+	return validationError{msg: msg, code: util.ErrCodeInvalidRequest}
+}
+
+// newParameterError returns a validationError of the given code, caused by the given parameter
+func newParameterError(code string, param string, msg string) validationError {
+	return validationError{msg: msg, code: code, param: param}
 }
 
 func (e validationError) Error() string {
 	return e.msg
 }
 
-func NewHandler(service service.ConceptSearchService) *Handler {
-	return &Handler{service}
+func (e validationError) Code() string {
+	return e.code
+}
+
+func (e validationError) Parameter() string {
+	return e.param
+}
+
+// NewHandler returns the handler of the concept search endpoints. The scores of the typeahead searches can only be
+// explained (with explain=true) when explainEnabled is set, as the explanations are large and costly to compute.
+func NewHandler(service service.ConceptSearchService, maxAges CacheMaxAges, explainEnabled bool) *Handler {
+	return &Handler{service, maxAges, explainEnabled}
 }
 
 func (h *Handler) ConceptSearch(w http.ResponseWriter, req *http.Request) {
 	response := make(map[string]interface{})
 	var err error
-	var concepts []service.Concept
+	var result service.SearchResult
+	maxAge := h.maxAges.Listing
 
 	mode, foundMode, modeErr := util.GetSingleValueQueryParameter(req, "mode", "search")
 	q, foundQ, qErr := util.GetSingleValueQueryParameter(req, "q")
 	conceptTypes, foundConceptTypes := util.GetMultipleValueQueryParameter(req, "type")
 	boostType, foundBoostType, boostTypeErr := util.GetSingleValueQueryParameter(req, "boost") // we currently only accept authors, so ignoring the actual boost value
 	ids, foundIds := util.GetMultipleValueQueryParameter(req, "ids")
+	authorities, foundAuthorities := util.GetMultipleValueQueryParameter(req, "authority")
 	includeDeprecated, _, includeDeprecatedErr := util.GetBoolQueryParameter(req, "include_deprecated", false)
 	searchAllAuthorities, _, searchAllErr := util.GetBoolQueryParameter(req, "searchAllAuthorities", false)
+	includeMeta, _, includeMetaErr := util.GetBoolQueryParameter(req, "include_meta", false)
+	includeFacets, foundIncludeFacets, includeFacetsErr := util.GetBoolQueryParameter(req, "include_facets", false)
+	fuzzy, foundFuzzy, fuzzyErr := util.GetBoolQueryParameter(req, "fuzzy", false)
+	fuzziness, foundFuzziness, fuzzinessErr := util.GetSingleValueQueryParameter(req, "fuzziness")
+	if fuzzyErr == nil && fuzzinessErr == nil {
+		fuzziness, fuzzinessErr = util.ResolveFuzziness(fuzzy, fuzziness)
+	}
+	foundFuzzySearch := foundFuzzy || foundFuzziness
+	highlight, foundHighlight, highlightErr := util.GetBoolQueryParameter(req, "highlight", false)
+	profile, foundProfile, profileErr := util.GetSingleValueQueryParameter(req, "profile")
+	explain, foundExplain, explainErr := util.GetBoolQueryParameter(req, "explain", false)
+	from, foundFrom, fromErr := util.GetIntQueryParameter(req, "from", 0)
+	size, foundSize, sizeErr := util.GetIntQueryParameter(req, "size", 0)
+	searchAfter, foundSearchAfter, searchAfterErr := util.GetSingleValueQueryParameter(req, "search_after")
+	modifiedSince, foundModifiedSince, modifiedSinceErr := util.GetTimeQueryParameter(req, "modifiedSince")
+	sortOrder, foundSort, sortErr := util.GetSingleValueQueryParameter(req, "sort")
+	fields, foundFields, fieldsErr := getFieldsParameter(req, conceptFields)
+	includeMetrics, foundIncludeMetrics, includeMetricsErr := util.GetBoolQueryParameter(req, "include_metrics", false)
+	if includeMetrics && len(fields) > 0 && !contains(fields, "metrics") {
+		fields = append(fields, "metrics")
+	}
+	foundPaging := foundFrom || foundSize || foundSearchAfter
 
-	err = util.FirstError(modeErr, qErr, boostTypeErr, includeDeprecatedErr, searchAllErr)
+	err = util.FirstError(modeErr, qErr, boostTypeErr, includeDeprecatedErr, searchAllErr, includeMetaErr, includeFacetsErr, fuzzyErr, fuzzinessErr, highlightErr, profileErr, explainErr, fromErr, sizeErr, searchAfterErr, modifiedSinceErr, sortErr, fieldsErr, includeMetricsErr)
 	if err != nil {
-		writeHTTPError(w, http.StatusBadRequest, err)
+		writeServiceError(w, req, err)
 		return
 	}
 	if foundIds {
-		if foundBoostType || foundQ || foundConceptTypes || foundMode {
-			err = NewValidationError("invalid parameters, 'ids' cannot be combined with any other parameter")
+		if foundBoostType || foundQ || foundConceptTypes || foundMode || foundPaging || foundIncludeFacets || foundAuthorities || foundModifiedSince || foundFuzzySearch || foundHighlight || foundProfile || foundExplain || foundSort || foundFields || foundIncludeMetrics {
+			err = newParameterError(util.ErrCodeUnsupportedParameter, "ids", "invalid parameters, 'ids' cannot be combined with any other parameter")
 		} else {
-			concepts, err = h.service.FindConceptsById(ids)
+			result, err = h.service.FindConceptsById(req.Context(), ids)
+			maxAge = h.maxAges.Ids
 		}
 	} else {
 		if foundMode {
 			if !foundConceptTypes {
-				err = NewValidationError("invalid or missing parameters for concept search (require type)")
+				err = newParameterError(util.ErrCodeMissingParameter, "type", "invalid or missing parameters for concept search (require type)")
+			} else if foundPaging {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, pagingParameter(foundFrom, foundSize), "invalid parameters, paging is only supported when listing concepts by type")
+			} else if foundModifiedSince {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, "modifiedSince", "invalid parameters, 'modifiedSince' is only supported when listing concepts by type")
+			} else if foundSort {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, "sort", "invalid parameters, sorting is only supported when listing concepts by type")
+			} else if explain && !h.explainEnabled {
+				err = newParameterError(util.ErrCodeFeatureDisabled, "explain", "invalid parameters, score explanations are not enabled")
 			} else {
 				if mode == "search" {
-					concepts, err = h.searchConcepts(foundBoostType, boostType, foundQ, q, conceptTypes, searchAllAuthorities, includeDeprecated)
+					maxAge = h.maxAges.Search
+					result, err = h.searchConcepts(req.Context(), foundBoostType, boostType, foundQ, q, conceptTypes, service.SearchOptions{
+						SearchAllAuthorities: searchAllAuthorities,
+						IncludeDeprecated:    includeDeprecated,
+						Authorities:          authorities,
+						IncludeFacets:        includeFacets,
+						Fuzziness:            fuzziness,
+						Highlight:            highlight,
+						Profile:              profile,
+						Explain:              explain,
+						Fields:               fields,
+						IncludeMetrics:       includeMetrics,
+					})
 				}
 			}
 		} else {
 			if foundQ {
-				err = NewValidationError("invalid or missing parameters for concept search (q but no mode)")
+				err = newParameterError(util.ErrCodeMissingParameter, "mode", "invalid or missing parameters for concept search (q but no mode)")
 			} else if foundBoostType {
-				err = NewValidationError("invalid or missing parameters for concept search (boost but no mode)")
+				err = newParameterError(util.ErrCodeMissingParameter, "mode", "invalid or missing parameters for concept search (boost but no mode)")
+			} else if foundIncludeFacets {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, "include_facets", "invalid parameters, facets are only supported when searching concepts (mode=search)")
+			} else if foundFuzzySearch {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, fuzzyParameter(foundFuzzy), "invalid parameters, fuzzy matching is only supported when searching concepts (mode=search)")
+			} else if foundHighlight {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, "highlight", "invalid parameters, highlighting is only supported when searching concepts (mode=search)")
+			} else if foundProfile {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, "profile", "invalid parameters, ranking profiles are only supported when searching concepts (mode=search)")
+			} else if foundExplain {
+				err = newParameterError(util.ErrCodeUnsupportedParameter, "explain", "invalid parameters, score explanations are only supported when searching concepts (mode=search)")
 			} else if foundConceptTypes {
-				concepts, err = h.findConceptsByType(conceptTypes, includeDeprecated, searchAllAuthorities)
+				result, err = h.findConceptsByType(req.Context(), conceptTypes, service.ListOptions{
+					SearchAllAuthorities: searchAllAuthorities,
+					IncludeDeprecated:    includeDeprecated,
+					Authorities:          authorities,
+					ModifiedSince:        modifiedSince,
+					From:                 from,
+					Size:                 size,
+					SearchAfter:          searchAfter,
+					Sort:                 sortOrder,
+					Fields:               fields,
+					IncludeMetrics:       includeMetrics,
+				})
 			} else {
-				err = NewValidationError("invalid or missing parameters for concept search")
+				err = newParameterError(util.ErrCodeMissingParameter, "type", "invalid or missing parameters for concept search")
 			}
 		}
 	}
 
 	if err != nil {
-		switch err.(type) {
+		writeServiceError(w, req, err)
+		return
+	}
 
-		case validationError, util.InputError:
+	response["concepts"] = result.Concepts
+	if len(fields) > 0 {
+		concepts := []json.RawMessage{}
+		for _, concept := range result.Concepts {
+			encoded, err := pickFields(concept, fields)
+			if err != nil {
+				util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeInternalError, err)
+				return
+			}
+			concepts = append(concepts, encoded)
+		}
+		response["concepts"] = concepts
+	}
+	if result.SearchAfter != "" {
+		response["search_after"] = result.SearchAfter
+	}
+	if includeMeta {
+		response["total"] = result.Total
+		response["took"] = result.Took
+		response["index"] = result.Index
+		response["limit"] = result.Limit
+		if result.Profile != "" {
+			response["profile"] = result.Profile
+		}
+	}
+	if includeFacets {
+		response["facets"] = result.Facets
+	}
+	writeCacheableResponse(w, req, response, maxAge)
+}
 
-			writeHTTPError(w, http.StatusBadRequest, err)
+func (h *Handler) GetConcept(w http.ResponseWriter, req *http.Request) {
+	conceptUUID := vestigo.Param(req, "uuid")
+	if _, err := uuid.FromString(conceptUUID); err != nil {
+		writeServiceError(w, req, newParameterError(util.ErrCodeInvalidParameter, "uuid", fmt.Sprintf("invalid concept uuid '%s'", conceptUUID)))
+		return
+	}
 
-		default:
-			if err == util.ErrNoElasticClient || err == elastic.ErrNoClient {
-				writeHTTPError(w, http.StatusServiceUnavailable, err)
-			} else {
+	searchAllAuthorities, _, err := util.GetBoolQueryParameter(req, "searchAllAuthorities", false)
+	if err != nil {
+		writeServiceError(w, req, err)
+		return
+	}
 
-				writeHTTPError(w, http.StatusInternalServerError, err)
-			}
-		}
+	concept, err := h.service.FindConceptByUUID(req.Context(), conceptUUID, searchAllAuthorities)
+	if err != nil {
+		writeServiceError(w, req, err)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(concept)
+}
+
+// LookupConcepts fetches the concepts with the ids posted as a JSON array, answering with the concept found for each
+// of them (or null) along with the ids which were only found in the extended index.
+func (h *Handler) LookupConcepts(w http.ResponseWriter, req *http.Request) {
+	var ids []string
+	if err := json.NewDecoder(req.Body).Decode(&ids); err != nil {
+		writeServiceError(w, req, newParameterError(util.ErrCodeInvalidBody, "", "invalid request body, it should be a JSON array of concept ids"))
+		return
+	}
+
+	result, err := h.service.LookupConcepts(req.Context(), ids)
+	if err != nil {
+		writeServiceError(w, req, err)
 		return
 	}
 
-	response["concepts"] = concepts
+	response := make(map[string]interface{})
+	response["concepts"] = result.Concepts
+	response["fromExtendedIndex"] = result.FromExtendedIndex
 	w.Header().Add("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *Handler) searchConcepts(foundBoostType bool, boostType string, foundQ bool, q string, conceptTypes []string, searchAllAuthorities bool, includeDeprecated bool) ([]service.Concept, error) {
+func (h *Handler) ExportConcepts(w http.ResponseWriter, req *http.Request) {
+	conceptType, foundConceptType, typeErr := util.GetSingleValueQueryParameter(req, "type")
+	includeDeprecated, _, includeDeprecatedErr := util.GetBoolQueryParameter(req, "include_deprecated", false)
+	searchAllAuthorities, _, searchAllErr := util.GetBoolQueryParameter(req, "searchAllAuthorities", false)
+	authorities, _ := util.GetMultipleValueQueryParameter(req, "authority")
+	modifiedSince, _, modifiedSinceErr := util.GetTimeQueryParameter(req, "modifiedSince")
+
+	err := util.FirstError(typeErr, includeDeprecatedErr, searchAllErr, modifiedSinceErr)
+	if err == nil && !foundConceptType {
+		err = newParameterError(util.ErrCodeMissingParameter, "type", "invalid or missing parameters for concept export (require type)")
+	}
+	if err != nil {
+		writeServiceError(w, req, err)
+		return
+	}
+
+	opts := service.ListOptions{
+		SearchAllAuthorities: searchAllAuthorities,
+		IncludeDeprecated:    includeDeprecated,
+		Authorities:          authorities,
+		ModifiedSince:        modifiedSince,
+	}
+
+	// the response is only committed to once the first concept is found, so that early errors can still be reported
+	exported := 0
+	encoder := json.NewEncoder(w)
+	err = h.service.ExportConceptsByType(req.Context(), conceptType, opts, func(concept service.EsConceptModel) error {
+		if exported == 0 {
+			w.Header().Add("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		exported++
+		return encoder.Encode(concept)
+	})
+
+	if err != nil {
+		if exported == 0 {
+			writeServiceError(w, req, err)
+			return
+		}
+		log.WithError(err).WithField("type", conceptType).WithField("exported", exported).Error("concept export aborted")
+		return
+	}
+	if exported == 0 {
+		w.Header().Add("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (h *Handler) searchConcepts(ctx context.Context, foundBoostType bool, boostType string, foundQ bool, q string, conceptTypes []string, opts service.SearchOptions) (service.SearchResult, error) {
 	if !foundQ {
-		return nil, NewValidationError("invalid or missing parameters for concept search (require q)")
+		return service.SearchResult{}, newParameterError(util.ErrCodeMissingParameter, "q", "invalid or missing parameters for concept search (require q)")
 	} else if foundBoostType {
-		return h.service.SearchConceptByTextAndTypesWithBoost(q, conceptTypes, boostType, searchAllAuthorities, includeDeprecated)
+		return h.service.SearchConceptByTextAndTypesWithBoost(ctx, q, conceptTypes, boostType, opts)
 	}
-	return h.service.SearchConceptByTextAndTypes(q, conceptTypes, searchAllAuthorities, includeDeprecated)
+	return h.service.SearchConceptByTextAndTypes(ctx, q, conceptTypes, opts)
 }
 
-func (h *Handler) findConceptsByType(conceptTypes []string, includeDeprecated bool, searchAllAuthorities bool) ([]service.Concept, error) {
+func (h *Handler) findConceptsByType(ctx context.Context, conceptTypes []string, opts service.ListOptions) (service.SearchResult, error) {
 	if len(conceptTypes) == 0 {
-		return []service.Concept{}, nil
+		return service.SearchResult{Concepts: []service.Concept{}}, nil
 	}
-
 	if len(conceptTypes) > 1 {
-		return nil, NewValidationError("only a single type is supported by this kind of request")
+		return service.SearchResult{}, newParameterError(util.ErrCodeInvalidParameter, "type", "only a single type is supported by this kind of request")
+	}
+	return h.service.FindAllConceptsByType(ctx, conceptTypes[0], opts)
+}
+
+// writeServiceError answers a request with the status and the ErrorResponse of the error which failed it
+func writeServiceError(w http.ResponseWriter, req *http.Request, err error) {
+	switch err.(type) {
+
+	case validationError, util.InputError:
+
+		util.WriteError(w, req, http.StatusBadRequest, util.ErrCodeInvalidRequest, err)
+
+	default:
+		if err == util.ErrNoElasticClient || err == elastic.ErrNoClient {
+			util.WriteError(w, req, http.StatusServiceUnavailable, util.ErrCodeSearchUnavailable, err)
+		} else if err == util.ErrConceptNotFound {
+			util.WriteError(w, req, http.StatusNotFound, util.ErrCodeNotFound, err)
+		} else if err == util.ErrQueryTimeout {
+			util.WriteError(w, req, http.StatusGatewayTimeout, util.ErrCodeSearchTimeout, err)
+		} else {
+
+			util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeSearchFailed, err)
+		}
 	}
+}
 
-	if strings.Contains(conceptTypes[0], "PublicCompany") {
-		return h.service.FindAllConceptsByDirectType(conceptTypes[0], searchAllAuthorities, includeDeprecated)
+// pagingParameter returns the paging parameter a request was given, for its errors to name
+func pagingParameter(foundFrom bool, foundSize bool) string {
+	if foundFrom {
+		return "from"
 	}
+	if foundSize {
+		return "size"
+	}
+	return "search_after"
+}
 
-	return h.service.FindAllConceptsByType(conceptTypes[0], searchAllAuthorities, includeDeprecated)
+// fuzzyParameter returns the fuzzy matching parameter a request was given, for its errors to name
+func fuzzyParameter(foundFuzzy bool) string {
+	if foundFuzzy {
+		return "fuzzy"
+	}
+	return "fuzziness"
 }
 
-func writeHTTPError(w http.ResponseWriter, status int, err error) {
-	response := make(map[string]interface{})
-	response["message"] = err.Error()
+// writeCacheableResponse writes the JSON response along with its caching headers. Its ETag is computed from the
+// encoded response, so that clients already holding it get a 304 Not Modified instead.
+func writeCacheableResponse(w http.ResponseWriter, req *http.Request, response interface{}, maxAge time.Duration) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeInternalError, err)
+		return
+	}
+	body = append(body, '\n') // as written by a json.Encoder
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl(maxAge))
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(response)
+	w.Write(body)
+}
+
+func cacheControl(maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+	return fmt.Sprintf("max-age=%d", int(maxAge.Seconds()))
+}
+
+// etagMatches tells whether any of the ETags of an If-None-Match header is the given one.
+// As required for If-None-Match, weak ETags are compared as if they were strong.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
 }
@@ -1,19 +1,24 @@
 package resources
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
 	"github.com/Financial-Times/concept-search-api/util"
 	"github.com/husobee/vestigo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/olivere/elastic.v5"
 )
 
@@ -25,33 +30,61 @@ type mockConceptSearchService struct {
 	mock.Mock
 }
 
-func (s *mockConceptSearchService) FindAllConceptsByType(conceptType string, searchAllAuthorities bool, includeDeprecated bool) ([]service.Concept, error) {
-	args := s.Called(conceptType, searchAllAuthorities, includeDeprecated)
-	return args.Get(0).([]service.Concept), args.Error(1)
+func (s *mockConceptSearchService) FindAllConceptsByType(ctx context.Context, conceptType string, opts service.ListOptions) (service.SearchResult, error) {
+	args := s.Called(conceptType, opts)
+	return args.Get(0).(service.SearchResult), args.Error(1)
 }
 
-func (s *mockConceptSearchService) FindAllConceptsByDirectType(conceptType string, searchAllAuthorities bool, includeDeprecated bool) ([]service.Concept, error) {
-	args := s.Called(conceptType, searchAllAuthorities, includeDeprecated)
-	return args.Get(0).([]service.Concept), args.Error(1)
+func (s *mockConceptSearchService) FindConceptsById(ctx context.Context, ids []string) (service.SearchResult, error) {
+	args := s.Called(ids)
+	return args.Get(0).(service.SearchResult), args.Error(1)
+}
+
+func (s *mockConceptSearchService) FindConceptByUUID(ctx context.Context, uuid string, searchAllAuthorities bool) (service.EsConceptModel, error) {
+	args := s.Called(uuid, searchAllAuthorities)
+	return args.Get(0).(service.EsConceptModel), args.Error(1)
 }
 
-func (s *mockConceptSearchService) FindConceptsById(ids []string) ([]service.Concept, error) {
+func (s *mockConceptSearchService) LookupConcepts(ctx context.Context, ids []string) (service.LookupResult, error) {
 	args := s.Called(ids)
-	return args.Get(0).([]service.Concept), args.Error(1)
+	return args.Get(0).(service.LookupResult), args.Error(1)
+}
+
+func (s *mockConceptSearchService) SearchConceptByTextAndTypes(ctx context.Context, textQuery string, conceptTypes []string, opts service.SearchOptions) (service.SearchResult, error) {
+	args := s.Called(textQuery, conceptTypes, opts)
+	return args.Get(0).(service.SearchResult), args.Error(1)
 }
 
-func (s *mockConceptSearchService) SearchConceptByTextAndTypes(textQuery string, conceptTypes []string, searchAllAuthorities bool, includeDeprecated bool) ([]service.Concept, error) {
-	args := s.Called(textQuery, conceptTypes, searchAllAuthorities, includeDeprecated)
-	return args.Get(0).([]service.Concept), args.Error(1)
+func (s *mockConceptSearchService) ExportConceptsByType(ctx context.Context, conceptType string, opts service.ListOptions, handle func(service.EsConceptModel) error) error {
+	args := s.Called(conceptType, opts, handle)
+	if concepts, ok := args.Get(0).([]service.EsConceptModel); ok {
+		for _, c := range concepts {
+			if err := handle(c); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
 }
 
 func (s *mockConceptSearchService) SetElasticClient(client *elastic.Client) {
 	s.Called(client)
 }
 
-func (s *mockConceptSearchService) SearchConceptByTextAndTypesWithBoost(textQuery string, conceptTypes []string, boostType string, searchAllAuthorities bool, includeDeprecated bool) ([]service.Concept, error) {
-	args := s.Called(textQuery, conceptTypes, boostType, searchAllAuthorities, includeDeprecated)
-	return args.Get(0).([]service.Concept), args.Error(1)
+func (s *mockConceptSearchService) SearchConceptByTextAndTypesWithBoost(ctx context.Context, textQuery string, conceptTypes []string, boostType string, opts service.SearchOptions) (service.SearchResult, error) {
+	args := s.Called(textQuery, conceptTypes, boostType, opts)
+	return args.Get(0).(service.SearchResult), args.Error(1)
+}
+
+func (s *mockConceptSearchService) SearchConceptsByTerm(ctx context.Context, term string, opts service.TermSearchOptions) (service.TermSearchResult, error) {
+	args := s.Called(term, opts)
+	return args.Get(0).(service.TermSearchResult), args.Error(1)
+}
+
+func (s *mockConceptSearchService) FindBestMatchingConcepts(ctx context.Context, terms []string, opts service.TermSearchOptions) (map[string]*service.ScoredConcept, error) {
+	args := s.Called(terms, opts)
+	matches, _ := args.Get(0).(map[string]*service.ScoredConcept)
+	return matches, args.Error(1)
 }
 
 func dummyConcepts() []service.Concept {
@@ -99,7 +132,7 @@ func TestAllConceptsByType(t *testing.T) {
 
 	concepts := dummyConcepts()
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return(concepts, nil)
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
@@ -117,7 +150,7 @@ func TestAllConceptsByTypeIncludeAllAuthorities(t *testing.T) {
 
 	concepts := dummyAllAutoritiesConcepts()
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", true, mock.AnythingOfType("bool")).Return(concepts, nil)
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{SearchAllAuthorities: true}).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
@@ -130,10 +163,201 @@ func TestAllConceptsByTypeIncludeAllAuthorities(t *testing.T) {
 	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
 }
 
+func TestAllConceptsByTypeSection(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FSection", nil)
+
+	concepts := []service.Concept{
+		service.Concept{
+			Id:          "http://api.ft.com/things/1",
+			ApiUrl:      "http://api.ft.com/things/1",
+			PrefLabel:   "Test Section 1",
+			ConceptType: "http://www.ft.com/ontology/Section",
+		},
+	}
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Section", mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.Len(t, respObject["concepts"], 1, "concepts")
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeSectionsAndSubjects(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Section&type=http://www.ft.com/ontology/Subject&mode=search&q=pippo", nil)
+	svc := &mockConceptSearchService{}
+
+	concepts := dummyConcepts()
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/Section", "http://www.ft.com/ontology/Subject"}, mock.AnythingOfType("service.SearchOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.Len(t, respObject["concepts"], 2, "concepts")
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypePaging(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&size=2&search_after=abc", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{Size: 2, SearchAfter: "abc"}).Return(service.SearchResult{Concepts: concepts, SearchAfter: "def"}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := struct {
+		Concepts    []service.Concept `json:"concepts"`
+		SearchAfter string            `json:"search_after"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+
+	assert.True(t, reflect.DeepEqual(respObject.Concepts, concepts))
+	assert.Equal(t, "def", respObject.SearchAfter, "search_after cursor")
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeFrom(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&from=2&size=2", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{From: 2, Size: 2}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeInvalidSize(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&size=lots", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "'lots' is not a valid value for parameter 'size'", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeWithAuthorities(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2Forganisation%2FOrganisation&searchAllAuthorities=true&authority=FACTSET", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/organisation/Organisation", service.ListOptions{SearchAllAuthorities: true, Authorities: []string{"FACTSET"}}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithAuthorities(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&authority=TME&authority=Smartlogic", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Authorities: []string{"TME", "Smartlogic"}}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeModifiedSince(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&modifiedSince=2019-05-13T10:12:02Z&size=50", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{ModifiedSince: time.Date(2019, 5, 13, 10, 12, 2, 0, time.UTC), Size: 50}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeInvalidModifiedSince(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&modifiedSince=yesterday", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "'yesterday' is not a valid RFC3339 value for parameter 'modifiedSince'", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithModifiedSince(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&modifiedSince=2019-05-13T10:12:02Z", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid parameters, 'modifiedSince' is only supported when listing concepts by type", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithPaging(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&from=10", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid parameters, paging is only supported when listing concepts by type", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
 func TestAllConceptsByTypeInputError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FFoo", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, expectedInputErr)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, expectedInputErr)
 
 	actual := doHttpCall(svc, req)
 
@@ -148,7 +372,7 @@ func TestAllConceptsByTypeInputError(t *testing.T) {
 func TestAllConceptByTypeNoElasticsearchError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FFoo", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, elastic.ErrNoClient)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, elastic.ErrNoClient)
 
 	actual := doHttpCall(svc, req)
 
@@ -163,7 +387,7 @@ func TestAllConceptByTypeNoElasticsearchError(t *testing.T) {
 func TestAllConceptByTypeNoElasticsearchClientError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FFoo", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, util.ErrNoElasticClient)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, util.ErrNoElasticClient)
 
 	actual := doHttpCall(svc, req)
 
@@ -180,7 +404,7 @@ func TestAllConceptByTypeServerError(t *testing.T) {
 
 	expectedError := errors.New("Test error")
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, expectedError)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, expectedError)
 
 	actual := doHttpCall(svc, req)
 
@@ -192,12 +416,39 @@ func TestAllConceptByTypeServerError(t *testing.T) {
 	assert.Equal(t, expectedError.Error(), respObject["message"], "error message")
 }
 
-func TestAllConceptsByDirectType(t *testing.T) {
+func TestAllConceptByTypeQueryTimeout(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil)
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, util.ErrQueryTimeout)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, util.ErrQueryTimeout.Error(), respObject["message"], "error message")
+}
+
+func TestSearchModeQueryTimeout(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&q=lucy&mode=search", nil)
+	svc := &mockConceptSearchService{}
+	svc.On("SearchConceptByTextAndTypes", "lucy", []string{"http://www.ft.com/ontology/Genre"}, service.SearchOptions{}).Return(service.SearchResult{}, util.ErrQueryTimeout)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, util.ErrQueryTimeout.Error(), respObject["message"], "error message")
+}
+
+func TestAllConceptsBySubtype(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2Fcompany%2FPublicCompany", nil)
 
 	concepts := dummyConcepts()
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByDirectType", "http://www.ft.com/ontology/company/PublicCompany", mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return(concepts, nil)
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/company/PublicCompany", mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
@@ -210,12 +461,12 @@ func TestAllConceptsByDirectType(t *testing.T) {
 	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
 }
 
-func TestAllConceptsByDirectTypeIncludeAllAuthorities(t *testing.T) {
+func TestAllConceptsBySubtypeIncludeAllAuthorities(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2Fcompany%2FPublicCompany&searchAllAuthorities=true", nil)
 
 	concepts := dummyAllAutoritiesConcepts()
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByDirectType", "http://www.ft.com/ontology/company/PublicCompany", true, mock.AnythingOfType("bool")).Return(concepts, nil)
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/company/PublicCompany", service.ListOptions{SearchAllAuthorities: true}).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
@@ -228,10 +479,10 @@ func TestAllConceptsByDirectTypeIncludeAllAuthorities(t *testing.T) {
 	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
 }
 
-func TestAllConceptsByDirectTypeIncorrectParam(t *testing.T) {
+func TestAllConceptsBySubtypeIncorrectParam(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2FPublicCompany", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByDirectType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, expectedInputErr)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, expectedInputErr)
 
 	actual := doHttpCall(svc, req)
 
@@ -243,10 +494,10 @@ func TestAllConceptsByDirectTypeIncorrectParam(t *testing.T) {
 	assert.Equal(t, expectedInputErr.Error(), respObject["message"], "error message")
 }
 
-func TestAllConceptsByDirectTypeNoElasticsearchError(t *testing.T) {
+func TestAllConceptsBySubtypeNoElasticsearchError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2FPublicCompany", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByDirectType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, elastic.ErrNoClient)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, elastic.ErrNoClient)
 
 	actual := doHttpCall(svc, req)
 
@@ -258,10 +509,10 @@ func TestAllConceptsByDirectTypeNoElasticsearchError(t *testing.T) {
 	assert.Equal(t, elastic.ErrNoClient.Error(), respObject["message"], "error message")
 }
 
-func TestAllConceptsByDirectTypeNoElasticsearchClientError(t *testing.T) {
+func TestAllConceptsBySubtypeNoElasticsearchClientError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2FPublicCompany", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByDirectType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, util.ErrNoElasticClient)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, util.ErrNoElasticClient)
 
 	actual := doHttpCall(svc, req)
 
@@ -273,12 +524,12 @@ func TestAllConceptsByDirectTypeNoElasticsearchClientError(t *testing.T) {
 	assert.Equal(t, util.ErrNoElasticClient.Error(), respObject["message"], "error message")
 }
 
-func TestAllConceptsByDirectTypeServerError(t *testing.T) {
+func TestAllConceptsBySubtypeServerError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2FPublicCompany", nil)
 
 	expectedError := errors.New("Test error")
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByDirectType", mock.AnythingOfType("string"), mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, expectedError)
+	svc.On("FindAllConceptsByType", mock.AnythingOfType("string"), mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{}, expectedError)
 
 	actual := doHttpCall(svc, req)
 
@@ -386,7 +637,7 @@ func TestConceptSearchForAuthors(t *testing.T) {
 	svc := &mockConceptSearchService{}
 
 	concepts := dummyConcepts()
-	svc.On("SearchConceptByTextAndTypesWithBoost", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, "authors", mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return(concepts, nil)
+	svc.On("SearchConceptByTextAndTypesWithBoost", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, "authors", mock.AnythingOfType("service.SearchOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
@@ -404,7 +655,7 @@ func TestConceptSearchInvalidBoost(t *testing.T) {
 	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2Fperson%2FPerson&q=pippo&mode=search&boost=somethingThatWeDontSupport", nil)
 
 	svc := &mockConceptSearchService{}
-	svc.On("SearchConceptByTextAndTypesWithBoost", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, "somethingThatWeDontSupport", mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return([]service.Concept{}, expectedInputErr)
+	svc.On("SearchConceptByTextAndTypesWithBoost", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, "somethingThatWeDontSupport", mock.AnythingOfType("service.SearchOptions")).Return(service.SearchResult{}, expectedInputErr)
 
 	actual := doHttpCall(svc, req)
 
@@ -437,7 +688,7 @@ func TestSearchMode(t *testing.T) {
 	svc := &mockConceptSearchService{}
 
 	concepts := dummyConcepts()
-	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return(concepts, nil)
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, mock.AnythingOfType("service.SearchOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
@@ -451,153 +702,1127 @@ func TestSearchMode(t *testing.T) {
 	svc.AssertExpectations(t)
 }
 
-func TestSearchModeWithNoQ(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search", nil)
+func TestSearchModeIncludeMeta(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&include_meta=true", nil)
 	svc := &mockConceptSearchService{}
 
+	concepts := dummyConcepts()
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, mock.AnythingOfType("service.SearchOptions")).Return(service.SearchResult{Concepts: concepts, Total: 3412, Took: 12, Index: "concepts", Limit: 10}, nil)
+
 	actual := doHttpCall(svc, req)
 
-	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
 	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
 
-	respObject := unmarshallResponseMessage(t, actual)
-
-	assert.Equal(t, "invalid or missing parameters for concept search (require q)", respObject["message"], "error message")
+	respObject := struct {
+		Concepts []service.Concept `json:"concepts"`
+		Total    int64             `json:"total"`
+		Took     int64             `json:"took"`
+		Index    string            `json:"index"`
+		Limit    int               `json:"limit"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+
+	assert.True(t, reflect.DeepEqual(respObject.Concepts, concepts))
+	assert.Equal(t, int64(3412), respObject.Total, "total")
+	assert.Equal(t, int64(12), respObject.Took, "took")
+	assert.Equal(t, "concepts", respObject.Index, "index")
+	assert.Equal(t, 10, respObject.Limit, "limit")
 	svc.AssertExpectations(t)
 }
 
-func TestConceptsById(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
+func TestSearchModeWithoutMeta(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo", nil)
+	svc := &mockConceptSearchService{}
 
 	concepts := dummyConcepts()
-	svc := &mockConceptSearchService{}
-	svc.On("FindConceptsById", []string{"1", "2"}).Return(concepts, nil)
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, mock.AnythingOfType("service.SearchOptions")).Return(service.SearchResult{Concepts: concepts, Total: 3412, Took: 12, Index: "concepts", Limit: 10}, nil)
 
 	actual := doHttpCall(svc, req)
 
 	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
 
-	respObject := unmarshallResponse(t, actual)
+	respObject := make(map[string]interface{})
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
 
-	assert.Len(t, respObject["concepts"], 2, "concepts")
-	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	assert.Len(t, respObject, 1, "only concepts should be returned")
+	assert.Contains(t, respObject, "concepts")
+	svc.AssertExpectations(t)
 }
 
-func TestConceptsByIdInputError(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?ids=", nil)
-
+func TestSearchModeInvalidIncludeMeta(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&include_meta=maybe", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindConceptsById", []string{""}).Return([]service.Concept{}, expectedInputErr)
 
 	actual := doHttpCall(svc, req)
 
 	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
-
-	respObject := unmarshallResponseMessage(t, actual)
-
-	assert.Equal(t, expectedInputErr.Error(), respObject["message"], "error message")
+	svc.AssertExpectations(t)
 }
 
-func TestConceptsByIdParameterCombinationError(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?ids=1&q=xyz", nil)
-
+func TestSearchModeIncludeFacets(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&type=http://www.ft.com/ontology/organisation/Organisation&mode=search&q=pippo&include_facets=true", nil)
 	svc := &mockConceptSearchService{}
 
+	concepts := dummyConcepts()
+	facets := service.Facets{
+		"type": []service.FacetBucket{
+			{Value: "http://www.ft.com/ontology/person/Person", Count: 12},
+			{Value: "http://www.ft.com/ontology/organisation/Organisation", Count: 4},
+		},
+		"authorities":  []service.FacetBucket{{Value: "TME", Count: 16}},
+		"isDeprecated": []service.FacetBucket{{Value: "false", Count: 16}},
+		"isFTAuthor":   []service.FacetBucket{},
+	}
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person", "http://www.ft.com/ontology/organisation/Organisation"}, service.SearchOptions{IncludeFacets: true}).Return(service.SearchResult{Concepts: concepts, Facets: facets}, nil)
+
 	actual := doHttpCall(svc, req)
 
-	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
 	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
 
-	respObject := unmarshallResponseMessage(t, actual)
+	respObject := struct {
+		Concepts []service.Concept `json:"concepts"`
+		Facets   service.Facets    `json:"facets"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
 
-	assert.Equal(t, "invalid parameters, 'ids' cannot be combined with any other parameter", respObject["message"])
+	assert.True(t, reflect.DeepEqual(respObject.Concepts, concepts))
+	assert.Equal(t, facets, respObject.Facets, "facets")
 	svc.AssertExpectations(t)
 }
 
-func TestConceptsByIdNoElasticsearchError(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
-	svc := &mockConceptSearchService{}
-	svc.On("FindConceptsById", []string{"1", "2"}).Return([]service.Concept{}, elastic.ErrNoClient)
+func TestSearchModeFuzzy(t *testing.T) {
+	testCases := []struct {
+		query             string
+		expectedFuzziness string
+	}{
+		{"fuzzy=true", "AUTO"},
+		{"fuzzy=false", ""},
+		{"fuzziness=1", "1"},
+		{"fuzzy=true&fuzziness=2", "2"},
+	}
 
-	actual := doHttpCall(svc, req)
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=trunp&"+tc.query, nil)
+		svc := &mockConceptSearchService{}
 
-	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+		concepts := dummyConcepts()
+		svc.On("SearchConceptByTextAndTypes", "trunp", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Fuzziness: tc.expectedFuzziness}).Return(service.SearchResult{Concepts: concepts}, nil)
 
-	respObject := unmarshallResponseMessage(t, actual)
+		actual := doHttpCall(svc, req)
 
-	assert.Equal(t, elastic.ErrNoClient.Error(), respObject["message"], "error message")
+		assert.Equal(t, http.StatusOK, actual.StatusCode, "http status for %v", tc.query)
+		svc.AssertExpectations(t)
+	}
 }
 
-func TestConceptsByIdNoElasticsearchClientError(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
+func TestSearchModeInvalidFuzziness(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=trunp&fuzziness=3", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindConceptsById", []string{"1", "2"}).Return([]service.Concept{}, util.ErrNoElasticClient)
 
 	actual := doHttpCall(svc, req)
 
-	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
-
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
 	respObject := unmarshallResponseMessage(t, actual)
-
-	assert.Equal(t, util.ErrNoElasticClient.Error(), respObject["message"], "error message")
+	assert.Equal(t, util.ErrInvalidFuzzinessParameter.Error(), respObject["message"], "error message")
+	svc.AssertExpectations(t)
 }
 
-func TestConceptsByIdServerError(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
-	expectedError := errors.New("Test error")
+func TestConceptsByTypeFuzzy(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&fuzzy=true", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindConceptsById", []string{"1", "2"}).Return([]service.Concept{}, expectedError)
 
 	actual := doHttpCall(svc, req)
 
-	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
-
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
 	respObject := unmarshallResponseMessage(t, actual)
-
-	assert.Equal(t, expectedError.Error(), respObject["message"], "error message")
+	assert.Equal(t, "invalid parameters, fuzzy matching is only supported when searching concepts (mode=search)", respObject["message"], "error message")
+	svc.AssertExpectations(t)
 }
 
-func TestDeprecatedTypes(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&include_deprecated=true", nil)
+func TestSearchModeHighlight(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=donald&highlight=true", nil)
+	svc := &mockConceptSearchService{}
 
 	concepts := dummyConcepts()
-	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", mock.AnythingOfType("bool"), true).Return(concepts, nil)
+	concepts[0].Highlight = service.Highlight{"aliases": {"The <em>Donald</em>"}}
+	svc.On("SearchConceptByTextAndTypes", "donald", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Highlight: true}).Return(service.SearchResult{Concepts: concepts}, nil)
 
 	actual := doHttpCall(svc, req)
 
 	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
 
 	respObject := unmarshallResponse(t, actual)
-
-	assert.Len(t, respObject["concepts"], 2, "concepts")
 	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+	assert.Equal(t, service.Highlight{"aliases": {"The <em>Donald</em>"}}, respObject["concepts"][0].Highlight)
+	svc.AssertExpectations(t)
 }
 
-func TestWrongDeprecatedFlagVal(t *testing.T) {
-	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&include_deprecated=wrong", nil)
-
-	concepts := dummyConcepts()
+func TestConceptsByTypeHighlight(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&highlight=true", nil)
 	svc := &mockConceptSearchService{}
-	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", mock.AnythingOfType("bool"), mock.AnythingOfType("bool")).Return(concepts, nil)
 
 	actual := doHttpCall(svc, req)
 
 	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
-	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
-}
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid parameters, highlighting is only supported when searching concepts (mode=search)", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithProfile(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=donald&profile=popular&include_meta=true", nil)
+	svc := &mockConceptSearchService{}
+
+	concepts := dummyConcepts()
+	svc.On("SearchConceptByTextAndTypes", "donald", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Profile: "popular"}).Return(service.SearchResult{Concepts: concepts, Profile: "popular"}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := make(map[string]interface{})
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+	assert.Equal(t, "popular", respObject["profile"], "profile")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeUnknownProfile(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=donald&profile=unpopular", nil)
+	svc := &mockConceptSearchService{}
+
+	profileErr := util.NewInputError("unknown ranking profile unpopular")
+	svc.On("SearchConceptByTextAndTypes", "donald", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Profile: "unpopular"}).Return(service.SearchResult{}, profileErr)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, profileErr.Error(), respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestConceptsByTypeProfile(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&profile=popular", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid parameters, ranking profiles are only supported when searching concepts (mode=search)", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithExplain(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=donald&explain=true", nil)
+	svc := &mockConceptSearchService{}
+
+	concepts := dummyConcepts()
+	concepts[0].Explanation = &service.ScoreExplanation{Score: 17, Contributions: map[string]float64{"textMatch": 2, "exactMatch": 15}}
+	svc.On("SearchConceptByTextAndTypes", "donald", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Explain: true}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCallWithExplain(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	respObject := unmarshallResponse(t, actual)
+	assert.Equal(t, concepts[0].Explanation, respObject["concepts"][0].Explanation)
+	assert.Nil(t, respObject["concepts"][1].Explanation)
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithExplainDisabled(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=donald&explain=true", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid parameters, score explanations are not enabled", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithExplainFalse(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=donald&explain=false", nil)
+	svc := &mockConceptSearchService{}
+	svc.On("SearchConceptByTextAndTypes", "donald", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestConceptsByTypeExplain(t *testing.T) {
+	for _, url := range []string{"/concepts?type=http://www.ft.com/ontology/Genre&explain=true", "/concepts?type=http://www.ft.com/ontology/Genre&explain=maybe"} {
+		req := httptest.NewRequest("GET", url, nil)
+		svc := &mockConceptSearchService{}
+
+		actual := doHttpCallWithExplain(svc, req)
+
+		assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+		svc.AssertExpectations(t)
+	}
+
+	actual := doHttpCallWithExplain(&mockConceptSearchService{}, httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&explain=true", nil))
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid parameters, score explanations are only supported when searching concepts (mode=search)", respObject["message"], "error message")
+}
+
+func TestConceptsByIdProfile(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&profile=popular", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid parameters, 'ids' cannot be combined with any other parameter", respObject["message"])
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeInvalidIncludeFacets(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&include_facets=maybe", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestConceptsByTypeWithFacets(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&include_facets=true", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid parameters, facets are only supported when searching concepts (mode=search)", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithFields(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&fields=id,prefLabel&fields=score", nil)
+	svc := &mockConceptSearchService{}
+
+	concepts := dummyConcepts()
+	concepts[0].Score = 12.5
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Fields: []string{"id", "prefLabel", "score"}}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := struct {
+		Concepts []map[string]interface{} `json:"concepts"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	require.NoError(t, err)
+	require.Len(t, respObject.Concepts, 2)
+	assert.Equal(t, map[string]interface{}{"id": "http://api.ft.com/things/1", "prefLabel": "Test Genre 1", "score": 12.5}, respObject.Concepts[0])
+	assert.Equal(t, map[string]interface{}{"id": "http://api.ft.com/things/2", "prefLabel": "Test Genre 2"}, respObject.Concepts[1])
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeWithFields(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&fields=prefLabel,type", nil)
+	svc := &mockConceptSearchService{}
+
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{Fields: []string{"prefLabel", "type"}}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := struct {
+		Concepts []map[string]interface{} `json:"concepts"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	require.NoError(t, err)
+	require.Len(t, respObject.Concepts, 2)
+	assert.Equal(t, map[string]interface{}{"prefLabel": "Test Genre 1", "type": "http://www.ft.com/ontology/Genre"}, respObject.Concepts[0])
+	svc.AssertExpectations(t)
+}
+
+func TestAllConceptsByTypeSortedByPopularity(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&sort=popularity&include_metrics=true", nil)
+	svc := &mockConceptSearchService{}
+
+	concepts := dummyConcepts()
+	concepts[0].Metrics = &service.ConceptMetrics{AnnotationsCount: 120, PrevWeekAnnotationsCount: 8}
+	concepts[1].Metrics = &service.ConceptMetrics{AnnotationsCount: 14}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{Sort: "popularity", IncludeMetrics: true}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := struct {
+		Concepts []service.Concept `json:"concepts"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	require.NoError(t, err)
+	assert.Equal(t, concepts, respObject.Concepts, "the concepts should be returned with their metrics")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeIncludeMetricsWithFields(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/person/Person&mode=search&q=pippo&fields=id&include_metrics=true", nil)
+	svc := &mockConceptSearchService{}
+
+	concepts := dummyConcepts()
+	concepts[0].Metrics = &service.ConceptMetrics{AnnotationsCount: 120, PrevWeekAnnotationsCount: 8}
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{Fields: []string{"id", "metrics"}, IncludeMetrics: true}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := struct {
+		Concepts []map[string]interface{} `json:"concepts"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	require.NoError(t, err)
+	require.Len(t, respObject.Concepts, 2)
+	assert.Equal(t, map[string]interface{}{
+		"id":      "http://api.ft.com/things/1",
+		"metrics": map[string]interface{}{"annotationsCount": 120.0, "prevWeekAnnotationsCount": 8.0},
+	}, respObject.Concepts[0], "the metrics should be returned along with the picked fields")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchModeWithNoQ(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid or missing parameters for concept search (require q)", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestConceptsById(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptsById", []string{"1", "2"}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.Len(t, respObject["concepts"], 2, "concepts")
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+}
+
+func TestConceptsByIdInputError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptsById", []string{""}).Return(service.SearchResult{}, expectedInputErr)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, expectedInputErr.Error(), respObject["message"], "error message")
+}
+
+func TestConceptsByIdParameterCombinationError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&q=xyz", nil)
+
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid parameters, 'ids' cannot be combined with any other parameter", respObject["message"])
+	svc.AssertExpectations(t)
+}
+
+func TestConceptsByIdWithPaging(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&size=1", nil)
+
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid parameters, 'ids' cannot be combined with any other parameter", respObject["message"])
+	svc.AssertExpectations(t)
+}
+
+func TestConceptsByIdNoElasticsearchError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptsById", []string{"1", "2"}).Return(service.SearchResult{}, elastic.ErrNoClient)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, elastic.ErrNoClient.Error(), respObject["message"], "error message")
+}
+
+func TestConceptsByIdNoElasticsearchClientError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptsById", []string{"1", "2"}).Return(service.SearchResult{}, util.ErrNoElasticClient)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, util.ErrNoElasticClient.Error(), respObject["message"], "error message")
+}
+
+func TestConceptsByIdServerError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&ids=2", nil)
+	expectedError := errors.New("Test error")
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptsById", []string{"1", "2"}).Return(service.SearchResult{}, expectedError)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, expectedError.Error(), respObject["message"], "error message")
+}
+
+func TestDeprecatedTypes(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&include_deprecated=true", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{IncludeDeprecated: true}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponse(t, actual)
+
+	assert.Len(t, respObject["concepts"], 2, "concepts")
+	assert.True(t, reflect.DeepEqual(respObject["concepts"], concepts))
+}
+
+func TestWrongDeprecatedFlagVal(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&include_deprecated=wrong", nil)
+
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", mock.AnythingOfType("service.ListOptions")).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+}
+
+func dummyEsConcept() service.EsConceptModel {
+	ftAuthor := "false"
+	return service.EsConceptModel{
+		Id:               "http://www.ft.com/thing/61d707b5-6fab-3541-b017-49b72de80772",
+		ApiUrl:           "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772",
+		PrefLabel:        "Analysis",
+		Types:            []string{"http://www.ft.com/ontology/core/Thing", "http://www.ft.com/ontology/concept/Concept", "http://www.ft.com/ontology/Genre"},
+		DirectType:       "http://www.ft.com/ontology/Genre",
+		Aliases:          []string{"Analysis"},
+		Authorities:      []string{"TME"},
+		IsFTAuthor:       &ftAuthor,
+		Metrics:          &service.ConceptMetrics{AnnotationsCount: 12, PrevWeekAnnotationsCount: 3},
+		LastModified:     "2019-05-13T10:12:02.123Z",
+		PublishReference: "tid_test",
+	}
+}
+
+func TestGetConcept(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/61d707b5-6fab-3541-b017-49b72de80772", nil)
+
+	concept := dummyEsConcept()
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptByUUID", "61d707b5-6fab-3541-b017-49b72de80772", false).Return(concept, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	var respObject service.EsConceptModel
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+	assert.Equal(t, concept, respObject)
+	svc.AssertExpectations(t)
+}
+
+func TestGetConceptSearchAllAuthorities(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/61d707b5-6fab-3541-b017-49b72de80772?searchAllAuthorities=true", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptByUUID", "61d707b5-6fab-3541-b017-49b72de80772", true).Return(dummyEsConcept(), nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestGetConceptNotFound(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/61d707b5-6fab-3541-b017-49b72de80772", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptByUUID", "61d707b5-6fab-3541-b017-49b72de80772", false).Return(service.EsConceptModel{}, util.ErrConceptNotFound)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusNotFound, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, util.ErrConceptNotFound.Error(), respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestGetConceptInvalidUUID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/not-a-uuid", nil)
+
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid concept uuid 'not-a-uuid'", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestGetConceptInvalidSearchAllAuthorities(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/61d707b5-6fab-3541-b017-49b72de80772?searchAllAuthorities=maybe", nil)
+
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestGetConceptNoElasticsearchClientError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/61d707b5-6fab-3541-b017-49b72de80772", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("FindConceptByUUID", "61d707b5-6fab-3541-b017-49b72de80772", false).Return(service.EsConceptModel{}, util.ErrNoElasticClient)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestExportConcepts(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/export?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&include_deprecated=true&searchAllAuthorities=true", nil)
+
+	concept := dummyEsConcept()
+	svc := &mockConceptSearchService{}
+	svc.On("ExportConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{IncludeDeprecated: true, SearchAllAuthorities: true}, mock.Anything).Return([]service.EsConceptModel{concept, concept}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/x-ndjson", actual.Header.Get("Content-Type"), "content-type")
+
+	decoder := json.NewDecoder(actual.Body)
+	exported := 0
+	for decoder.More() {
+		var c service.EsConceptModel
+		err := decoder.Decode(&c)
+		assert.NoError(t, err)
+		assert.Equal(t, concept, c)
+		exported++
+	}
+	assert.Equal(t, 2, exported, "every concept should be exported on its own line")
+	svc.AssertExpectations(t)
+}
+
+func TestExportConceptsNoConcepts(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/export?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("ExportConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}, mock.Anything).Return(nil, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/x-ndjson", actual.Header.Get("Content-Type"), "content-type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Empty(t, body)
+	svc.AssertExpectations(t)
+}
+
+func TestExportConceptsMissingType(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/export", nil)
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid or missing parameters for concept export (require type)", respObject["message"], "error message")
+	svc.AssertExpectations(t)
+}
+
+func TestExportConceptsInputError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/export?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FFoo", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("ExportConceptsByType", "http://www.ft.com/ontology/Foo", service.ListOptions{}, mock.Anything).Return(nil, expectedInputErr)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+	svc.AssertExpectations(t)
+}
+
+func TestExportConceptsNoElasticsearchClientError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/export?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil)
+
+	svc := &mockConceptSearchService{}
+	svc.On("ExportConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}, mock.Anything).Return(nil, util.ErrNoElasticClient)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestExportConceptsFailureAfterStreaming(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts/export?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil)
+
+	concept := dummyEsConcept()
+	svc := &mockConceptSearchService{}
+	svc.On("ExportConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}, mock.Anything).Return([]service.EsConceptModel{concept}, errors.New("scroll expired"))
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "the response was already committed to")
+	var c service.EsConceptModel
+	err := json.NewDecoder(actual.Body).Decode(&c)
+	assert.NoError(t, err)
+	assert.Equal(t, concept, c)
+	svc.AssertExpectations(t)
+}
 
-func doHttpCall(svc *mockConceptSearchService, req *http.Request) *http.Response {
-	endpoint := NewHandler(svc)
+func newInMemoryConceptSearchService() service.ConceptSearchService {
+	repository := servicetest.NewInMemoryConceptRepository().Add("concepts", "genres",
+		service.EsConceptModel{Id: "http://api.ft.com/things/2a88a647-59bc-4043-8f1b-5add71ddb3a0", PrefLabel: "Analysis", DirectType: "http://www.ft.com/ontology/Genre"},
+		service.EsConceptModel{Id: "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", PrefLabel: "Comment", DirectType: "http://www.ft.com/ontology/Genre"},
+		service.EsConceptModel{Id: "http://api.ft.com/things/a4fda01c-5d3b-4e4b-b0ec-5a2e3a3c5b31", PrefLabel: "News", DirectType: "http://www.ft.com/ontology/Genre"},
+	).Add("all-concepts", "organisations",
+		service.EsConceptModel{Id: "http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400", PrefLabel: "Apple Inc", DirectType: "http://www.ft.com/ontology/company/PublicCompany"},
+	)
+	return service.NewConceptSearchService(repository, "concepts", "all-concepts", 50, 10)
+}
+
+func TestAllConceptsByTypePagingWithInMemoryRepository(t *testing.T) {
+	svc := newInMemoryConceptSearchService()
+
+	req := httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&size=2", nil)
+	actual := doHttpCall(svc, req)
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := struct {
+		Concepts    []service.Concept `json:"concepts"`
+		SearchAfter string            `json:"search_after"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+	assert.Len(t, respObject.Concepts, 2)
+	assert.Equal(t, "Analysis", respObject.Concepts[0].PrefLabel)
+	assert.Equal(t, "Comment", respObject.Concepts[1].PrefLabel)
+	assert.NotEmpty(t, respObject.SearchAfter, "search_after cursor")
+
+	req = httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&size=2&search_after="+respObject.SearchAfter, nil)
+	actual = doHttpCall(svc, req)
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject.Concepts = nil
+	respObject.SearchAfter = ""
+	err = json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+	assert.Len(t, respObject.Concepts, 1)
+	assert.Equal(t, "News", respObject.Concepts[0].PrefLabel)
+	assert.Empty(t, respObject.SearchAfter, "the last page should not have a cursor")
+}
+
+func TestGetConceptWithInMemoryRepository(t *testing.T) {
+	svc := newInMemoryConceptSearchService()
+
+	req := httptest.NewRequest("GET", "/concepts/61d707b5-6fab-3541-b017-49b72de80772", nil)
+	actual := doHttpCall(svc, req)
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	var respObject service.EsConceptModel
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://www.ft.com/thing/61d707b5-6fab-3541-b017-49b72de80772", respObject.Id)
+	assert.Equal(t, "Comment", respObject.PrefLabel)
+
+	req = httptest.NewRequest("GET", "/concepts/0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3", nil)
+	actual = doHttpCall(svc, req)
+	assert.Equal(t, http.StatusNotFound, actual.StatusCode, "http status")
+}
+
+func TestExportConceptsWithInMemoryRepository(t *testing.T) {
+	svc := newInMemoryConceptSearchService()
+
+	req := httptest.NewRequest("GET", "/concepts/export?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil)
+	actual := doHttpCall(svc, req)
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	decoder := json.NewDecoder(actual.Body)
+	exported := 0
+	for decoder.More() {
+		var c service.EsConceptModel
+		assert.NoError(t, decoder.Decode(&c))
+		exported++
+	}
+	assert.Equal(t, 3, exported)
+}
+
+func TestLookupConcepts(t *testing.T) {
+	ids := []string{"61d707b5-6fab-3541-b017-49b72de80772", "http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400", "0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3"}
+	concept := dummyConcepts()[0]
+	svc := &mockConceptSearchService{}
+	svc.On("LookupConcepts", ids).Return(service.LookupResult{
+		Concepts:          map[string]*service.Concept{ids[0]: &concept, ids[1]: &concept, ids[2]: nil},
+		FromExtendedIndex: []string{ids[1]},
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/concepts/lookup", strings.NewReader(`["61d707b5-6fab-3541-b017-49b72de80772", "http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400", "0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3"]`))
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+
+	var respObject map[string]json.RawMessage
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	require.NoError(t, err)
+
+	var concepts map[string]*service.Concept
+	require.NoError(t, json.Unmarshal(respObject["concepts"], &concepts))
+	assert.Len(t, concepts, 3)
+	assert.Equal(t, &concept, concepts[ids[0]])
+	assert.Equal(t, &concept, concepts[ids[1]])
+	assert.Contains(t, string(respObject["concepts"]), `"0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3":null`, "unknown ids should be mapped to null")
+
+	var fromExtendedIndex []string
+	require.NoError(t, json.Unmarshal(respObject["fromExtendedIndex"], &fromExtendedIndex))
+	assert.Equal(t, []string{ids[1]}, fromExtendedIndex)
+	svc.AssertExpectations(t)
+}
+
+func TestLookupConceptsInvalidBody(t *testing.T) {
+	for _, body := range []string{"", "{}", `"61d707b5-6fab-3541-b017-49b72de80772"`, "[1, 2]", "[\"61d707b5"} {
+		svc := &mockConceptSearchService{}
+		req := httptest.NewRequest("POST", "/concepts/lookup", strings.NewReader(body))
+		actual := doHttpCall(svc, req)
+
+		assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status for %v", body)
+		respObject := unmarshallResponseMessage(t, actual)
+		assert.Equal(t, "invalid request body, it should be a JSON array of concept ids", respObject["message"], "error message for %v", body)
+		svc.AssertExpectations(t)
+	}
+}
+
+func TestLookupConceptsInputError(t *testing.T) {
+	svc := &mockConceptSearchService{}
+	svc.On("LookupConcepts", []string{}).Return(service.LookupResult{}, util.NewInputError("empty Ids parameter"))
+
+	actual := doHttpCall(svc, httptest.NewRequest("POST", "/concepts/lookup", strings.NewReader("[]")))
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "empty Ids parameter", respObject["message"], "error message")
+}
+
+func TestLookupConceptsNoElasticsearchClientError(t *testing.T) {
+	svc := &mockConceptSearchService{}
+	svc.On("LookupConcepts", []string{"61d707b5-6fab-3541-b017-49b72de80772"}).Return(service.LookupResult{}, util.ErrNoElasticClient)
+
+	actual := doHttpCall(svc, httptest.NewRequest("POST", "/concepts/lookup", strings.NewReader(`["61d707b5-6fab-3541-b017-49b72de80772"]`)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, actual.StatusCode, "http status")
+}
+
+func TestLookupConceptsWithInMemoryRepository(t *testing.T) {
+	svc := newInMemoryConceptSearchService()
+
+	req := httptest.NewRequest("POST", "/concepts/lookup", strings.NewReader(`["61d707b5-6fab-3541-b017-49b72de80772", "eac853f5-3859-4c08-8540-55e043719400", "0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3"]`))
+	actual := doHttpCall(svc, req)
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+
+	respObject := struct {
+		Concepts          map[string]*service.Concept `json:"concepts"`
+		FromExtendedIndex []string                    `json:"fromExtendedIndex"`
+	}{}
+	err := json.NewDecoder(actual.Body).Decode(&respObject)
+	require.NoError(t, err)
+	require.Len(t, respObject.Concepts, 3)
+	assert.Equal(t, "Comment", respObject.Concepts["61d707b5-6fab-3541-b017-49b72de80772"].PrefLabel)
+	assert.Equal(t, "Apple Inc", respObject.Concepts["eac853f5-3859-4c08-8540-55e043719400"].PrefLabel)
+	assert.Nil(t, respObject.Concepts["0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3"])
+	assert.Equal(t, []string{"eac853f5-3859-4c08-8540-55e043719400"}, respObject.FromExtendedIndex)
+}
+
+func TestConceptsByIdsNormalisationWithInMemoryRepository(t *testing.T) {
+	svc := newInMemoryConceptSearchService()
+
+	testCases := []struct {
+		ids            string
+		expectedStatus int
+		expectedLabels []string
+		expectedMsg    string
+	}{
+		{"ids=61d707b5-6fab-3541-b017-49b72de80772", http.StatusOK, []string{"Comment"}, ""},
+		{"ids=http%3A%2F%2Fwww.ft.com%2Fthing%2F61d707b5-6fab-3541-b017-49b72de80772", http.StatusOK, []string{"Comment"}, ""},
+		{"ids=http%3A%2F%2Fapi.ft.com%2Fthings%2F61d707b5-6fab-3541-b017-49b72de80772&ids=http%3A%2F%2Fapi.ft.com%2Fgenres%2F2a88a647-59bc-4043-8f1b-5add71ddb3a0", http.StatusOK, []string{"Analysis", "Comment"}, ""},
+		{"ids=61d707b5&ids=http%3A%2F%2Fapi.ft.com%2Fthings%2F2a88a647-59bc-4043-8f1b-5add71ddb3a0&ids=Comment", http.StatusBadRequest, nil, "invalid concept ids '61d707b5', 'Comment' (they should be UUIDs or FT concept URIs)"},
+	}
+
+	for _, tc := range testCases {
+		actual := doHttpCall(svc, httptest.NewRequest("GET", "/concepts?"+tc.ids, nil))
+		assert.Equal(t, tc.expectedStatus, actual.StatusCode, "http status for %v", tc.ids)
+		if tc.expectedStatus != http.StatusOK {
+			respObject := unmarshallResponseMessage(t, actual)
+			assert.Equal(t, tc.expectedMsg, respObject["message"], "error message for %v", tc.ids)
+			continue
+		}
+
+		respObject := struct {
+			Concepts []service.Concept `json:"concepts"`
+		}{}
+		require.NoError(t, json.NewDecoder(actual.Body).Decode(&respObject))
+		labels := []string{}
+		for _, c := range respObject.Concepts {
+			labels = append(labels, c.PrefLabel)
+		}
+		assert.Equal(t, tc.expectedLabels, labels, "concepts for %v", tc.ids)
+	}
+}
+
+func TestLookupConceptsMalformedIdsWithInMemoryRepository(t *testing.T) {
+	svc := newInMemoryConceptSearchService()
+
+	req := httptest.NewRequest("POST", "/concepts/lookup", strings.NewReader(`["http://api.ft.com/people/61d707b5-6fab-3541-b017-49b72de80772", "not-a-uuid"]`))
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+	respObject := unmarshallResponseMessage(t, actual)
+	assert.Equal(t, "invalid concept ids 'not-a-uuid' (they should be UUIDs or FT concept URIs)", respObject["message"], "error message")
+}
+
+var testMaxAges = CacheMaxAges{Listing: 10 * time.Minute, Search: 30 * time.Second, Ids: 5 * time.Minute}
+
+func TestCacheControlPerMode(t *testing.T) {
+	concepts := dummyConcepts()
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}).Return(service.SearchResult{Concepts: concepts}, nil)
+	svc.On("SearchConceptByTextAndTypes", "lucy", []string{"http://www.ft.com/ontology/Genre"}, service.SearchOptions{}).Return(service.SearchResult{Concepts: concepts}, nil)
+	svc.On("FindConceptsById", []string{"61d707b5-6fab-3541-b017-49b72de80772"}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	testCases := []struct {
+		url          string
+		cacheControl string
+	}{
+		{"/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", "max-age=600"},
+		{"/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&q=lucy&mode=search", "max-age=30"},
+		{"/concepts?ids=61d707b5-6fab-3541-b017-49b72de80772", "max-age=300"},
+	}
+	for _, testCase := range testCases {
+		actual := doHttpCallWithMaxAges(svc, testMaxAges, httptest.NewRequest("GET", testCase.url, nil))
+
+		assert.Equal(t, http.StatusOK, actual.StatusCode, "http status for %v", testCase.url)
+		assert.Equal(t, testCase.cacheControl, actual.Header.Get("Cache-Control"), "cache-control for %v", testCase.url)
+		assert.NotEmpty(t, actual.Header.Get("ETag"), "etag for %v", testCase.url)
+	}
+	svc.AssertExpectations(t)
+}
+
+func TestCacheControlWithoutMaxAge(t *testing.T) {
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+
+	actual := doHttpCall(svc, httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil))
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "no-cache", actual.Header.Get("Cache-Control"), "cache-control")
+}
+
+func TestCacheControlNotSetOnErrors(t *testing.T) {
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}).Return(service.SearchResult{}, errors.New("Test error"))
+
+	actual := doHttpCallWithMaxAges(svc, testMaxAges, httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "http status")
+	assert.Empty(t, actual.Header.Get("Cache-Control"), "cache-control")
+	assert.Empty(t, actual.Header.Get("ETag"), "etag")
+}
+
+func TestETagIfNoneMatch(t *testing.T) {
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+	url := "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre"
+
+	actual := doHttpCallWithMaxAges(svc, testMaxAges, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	etag := actual.Header.Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{64}"$`, etag, "the etag should be a strong one")
+
+	again := doHttpCallWithMaxAges(svc, testMaxAges, httptest.NewRequest("GET", url, nil))
+	assert.Equal(t, etag, again.Header.Get("ETag"), "the etag should be stable for the same response")
+
+	for _, ifNoneMatch := range []string{etag, `"other", ` + etag, "W/" + etag, "*"} {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		actual = doHttpCallWithMaxAges(svc, testMaxAges, req)
+
+		assert.Equal(t, http.StatusNotModified, actual.StatusCode, "http status for If-None-Match %v", ifNoneMatch)
+		assert.Equal(t, etag, actual.Header.Get("ETag"), "etag")
+		assert.Equal(t, "max-age=600", actual.Header.Get("Cache-Control"), "cache-control")
+		body, _ := ioutil.ReadAll(actual.Body)
+		assert.Empty(t, body, "a 304 should have no body")
+	}
+
+	req := httptest.NewRequest("GET", url, nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	actual = doHttpCallWithMaxAges(svc, testMaxAges, req)
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "a stale etag should get the full response")
+}
+
+func TestETagChangesWithResponse(t *testing.T) {
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{IncludeDeprecated: true}).Return(service.SearchResult{Concepts: dummyAllAutoritiesConcepts()}, nil)
+
+	actual := doHttpCall(svc, httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre", nil))
+	other := doHttpCall(svc, httptest.NewRequest("GET", "/concepts?type=http%3A%2F%2Fwww.ft.com%2Fontology%2FGenre&include_deprecated=true", nil))
+
+	assert.NotEqual(t, actual.Header.Get("ETag"), other.Header.Get("ETag"))
+}
+
+func TestErrorResponses(t *testing.T) {
+	testCases := []struct {
+		name      string
+		method    string
+		url       string
+		err       error
+		status    int
+		code      string
+		parameter string
+	}{
+		{"malformed parameter", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&size=lots", nil, http.StatusBadRequest, "invalid_parameter", "size"},
+		{"missing parameter", "GET", "/concepts?mode=search&q=lucy", nil, http.StatusBadRequest, "missing_parameter", "type"},
+		{"unsupported parameter", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&fuzziness=1", nil, http.StatusBadRequest, "unsupported_parameter", "fuzziness"},
+		{"unknown field", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&fields=id,colour", nil, http.StatusBadRequest, "invalid_parameter", "fields"},
+		{"empty fields", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&fields=", nil, http.StatusBadRequest, "invalid_parameter", "fields"},
+		{"search with sort", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy&sort=popularity", nil, http.StatusBadRequest, "unsupported_parameter", "sort"},
+		{"ids with fields", "GET", "/concepts?ids=61d707b5-6fab-3541-b017-49b72de80772&fields=id", nil, http.StatusBadRequest, "unsupported_parameter", "ids"},
+		{"ids with metrics", "GET", "/concepts?ids=61d707b5-6fab-3541-b017-49b72de80772&include_metrics=true", nil, http.StatusBadRequest, "unsupported_parameter", "ids"},
+		{"malformed include metrics", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&include_metrics=maybe", nil, http.StatusBadRequest, "invalid_parameter", "include_metrics"},
+		{"conflicting parameters", "GET", "/concepts?ids=61d707b5-6fab-3541-b017-49b72de80772&q=lucy", nil, http.StatusBadRequest, "unsupported_parameter", "ids"},
+		{"disabled feature", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy&explain=true", nil, http.StatusBadRequest, "feature_disabled", "explain"},
+		{"invalid uuid", "GET", "/concepts/lucy", nil, http.StatusBadRequest, "invalid_parameter", "uuid"},
+		{"invalid body", "POST", "/concepts/lookup", nil, http.StatusBadRequest, "invalid_body", ""},
+		{"service input error", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy", util.NewParameterError(util.ErrCodeInvalidParameter, "profile", "unknown ranking profile"), http.StatusBadRequest, "invalid_parameter", "profile"},
+		{"generic input error", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy", expectedInputErr, http.StatusBadRequest, "invalid_request", ""},
+		{"no elasticsearch", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy", util.ErrNoElasticClient, http.StatusServiceUnavailable, "search_unavailable", ""},
+		{"query timeout", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy", util.ErrQueryTimeout, http.StatusGatewayTimeout, "search_timeout", ""},
+		{"search failure", "GET", "/concepts?type=http://www.ft.com/ontology/Genre&mode=search&q=lucy", errors.New("Test error"), http.StatusInternalServerError, "search_failed", ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.url, strings.NewReader("{"))
+		req.Header.Set("X-Request-Id", "tid_test")
+		svc := &mockConceptSearchService{}
+		if tc.err != nil {
+			svc.On("SearchConceptByTextAndTypes", "lucy", []string{"http://www.ft.com/ontology/Genre"}, service.SearchOptions{}).Return(service.SearchResult{}, tc.err)
+		}
+
+		actual := doHttpCall(svc, req)
+
+		assert.Equal(t, tc.status, actual.StatusCode, tc.name)
+		assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), tc.name)
+		respObject := unmarshallResponseMessage(t, actual)
+		assert.Equal(t, tc.code, respObject["code"], tc.name)
+		assert.Equal(t, tc.parameter, respObject["parameter"], tc.name)
+		assert.NotEmpty(t, respObject["message"], tc.name)
+		assert.Equal(t, "tid_test", respObject["transaction_id"], tc.name)
+		svc.AssertExpectations(t)
+	}
+}
+
+func doHttpCall(svc service.ConceptSearchService, req *http.Request) *http.Response {
+	return doHttpCallWithMaxAges(svc, CacheMaxAges{}, req)
+}
+
+func doHttpCallWithMaxAges(svc service.ConceptSearchService, maxAges CacheMaxAges, req *http.Request) *http.Response {
+	return serveHttpCall(NewHandler(svc, maxAges, false), req)
+}
+
+func doHttpCallWithExplain(svc service.ConceptSearchService, req *http.Request) *http.Response {
+	return serveHttpCall(NewHandler(svc, CacheMaxAges{}, true), req)
+}
+
+func serveHttpCall(endpoint *Handler, req *http.Request) *http.Response {
 
 	router := vestigo.NewRouter()
 	router.Get("/concepts", endpoint.ConceptSearch)
+	router.Get("/concepts/export", endpoint.ExportConcepts)
+	router.Post("/concepts/lookup", endpoint.LookupConcepts)
+	router.Post("/v2/concepts/search", endpoint.SearchConceptsV2)
+	router.Get("/concepts/:uuid", endpoint.GetConcept)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	return w.Result()
@@ -622,3 +1847,18 @@ func unmarshallResponse(t *testing.T, resp *http.Response) map[string][]service.
 	}
 	return respObject
 }
+
+func TestConceptsByIdWithAuthorities(t *testing.T) {
+	req := httptest.NewRequest("GET", "/concepts?ids=1&authority=TME", nil)
+
+	svc := &mockConceptSearchService{}
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "http status")
+
+	respObject := unmarshallResponseMessage(t, actual)
+
+	assert.Equal(t, "invalid parameters, 'ids' cannot be combined with any other parameter", respObject["message"])
+	svc.AssertExpectations(t)
+}
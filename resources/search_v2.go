@@ -0,0 +1,199 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+)
+
+// v2RequestFields names the fields of a v2 search request after the query parameters of GET /concepts, as named by
+// the errors of the search service
+var v2RequestFields = map[string]string{
+	"q":             "text",
+	"type":          "types",
+	"from":          "paging.from",
+	"size":          "paging.size",
+	"search_after":  "paging.searchAfter",
+	"modifiedSince": "filters.modifiedSince",
+}
+
+// v2SearchRequest is the payload of POST /v2/concepts/search. Concepts are searched for when it holds a text, and are
+// listed by type otherwise.
+type v2SearchRequest struct {
+	Text                 *string         `json:"text"`
+	Types                []string        `json:"types"`
+	Authorities          []string        `json:"authorities"`
+	SearchAllAuthorities bool            `json:"searchAllAuthorities"`
+	Filters              v2SearchFilters `json:"filters"`
+	Boost                string          `json:"boost"`
+	Fuzziness            string          `json:"fuzziness"`
+	Profile              string          `json:"profile"`
+	Paging               *v2SearchPaging `json:"paging"`
+	Sort                 string          `json:"sort"`
+	Fields               []string        `json:"fields"`
+	Facets               bool            `json:"facets"`
+	Highlight            bool            `json:"highlight"`
+	Explain              bool            `json:"explain"`
+}
+
+type v2SearchFilters struct {
+	IncludeDeprecated bool   `json:"includeDeprecated"`
+	ModifiedSince     string `json:"modifiedSince"`
+}
+
+type v2SearchPaging struct {
+	From        int    `json:"from"`
+	Size        int    `json:"size"`
+	SearchAfter string `json:"searchAfter"`
+}
+
+// v2SearchResponse is the envelope of the concepts found by POST /v2/concepts/search, whatever the kind of search
+type v2SearchResponse struct {
+	Concepts    []json.RawMessage `json:"concepts"`
+	Total       int64             `json:"total"`
+	Took        int64             `json:"took"`
+	Limit       int               `json:"limit"`
+	Profile     string            `json:"profile,omitempty"`
+	SearchAfter string            `json:"searchAfter,omitempty"`
+	Facets      service.Facets    `json:"facets,omitempty"`
+}
+
+// SearchConceptsV2 serves POST /v2/concepts/search, which searches for concepts by text or lists them by type from a
+// single JSON request, and answers both with the same response envelope.
+func (h *Handler) SearchConceptsV2(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var request v2SearchRequest
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeServiceError(w, req, newParameterError(util.ErrCodeInvalidBody, "", fmt.Sprintf("invalid request body, it should be a JSON object holding the search request: %v", err)))
+		return
+	}
+
+	err := validateFields(request.Fields, conceptFields)
+	if err == nil && request.Explain && !h.explainEnabled {
+		err = newParameterError(util.ErrCodeFeatureDisabled, "explain", "score explanations are not enabled")
+	}
+
+	var result service.SearchResult
+	if err == nil {
+		if request.Text != nil {
+			result, err = h.searchConceptsV2(req, request)
+		} else {
+			result, err = h.listConceptsV2(req, request)
+		}
+	}
+	if err != nil {
+		writeServiceError(w, req, v2RequestError(err))
+		return
+	}
+
+	response := v2SearchResponse{
+		Concepts:    []json.RawMessage{},
+		Total:       result.Total,
+		Took:        result.Took,
+		Limit:       result.Limit,
+		Profile:     result.Profile,
+		SearchAfter: result.SearchAfter,
+		Facets:      result.Facets,
+	}
+	for _, concept := range result.Concepts {
+		encoded, err := pickFields(concept, request.Fields)
+		if err != nil {
+			util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeInternalError, err)
+			return
+		}
+		response.Concepts = append(response.Concepts, encoded)
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) searchConceptsV2(req *http.Request, request v2SearchRequest) (service.SearchResult, error) {
+	if request.Paging != nil {
+		return service.SearchResult{}, newParameterError(util.ErrCodeUnsupportedParameter, "paging", "paging is only supported when listing concepts by type")
+	}
+	if request.Filters.ModifiedSince != "" {
+		return service.SearchResult{}, newParameterError(util.ErrCodeUnsupportedParameter, "filters.modifiedSince", "'modifiedSince' is only supported when listing concepts by type")
+	}
+	if request.Sort != "" {
+		return service.SearchResult{}, newParameterError(util.ErrCodeUnsupportedParameter, "sort", "sorting is only supported when listing concepts by type")
+	}
+
+	opts := service.SearchOptions{
+		SearchAllAuthorities: request.SearchAllAuthorities,
+		IncludeDeprecated:    request.Filters.IncludeDeprecated,
+		Authorities:          request.Authorities,
+		IncludeFacets:        request.Facets,
+		Fuzziness:            request.Fuzziness,
+		Highlight:            request.Highlight,
+		Profile:              request.Profile,
+		Explain:              request.Explain,
+		Fields:               request.Fields,
+	}
+	if request.Boost != "" {
+		return h.service.SearchConceptByTextAndTypesWithBoost(req.Context(), *request.Text, request.Types, request.Boost, opts)
+	}
+	return h.service.SearchConceptByTextAndTypes(req.Context(), *request.Text, request.Types, opts)
+}
+
+func (h *Handler) listConceptsV2(req *http.Request, request v2SearchRequest) (service.SearchResult, error) {
+	for _, option := range []struct {
+		field string
+		found bool
+	}{
+		{"boost", request.Boost != ""},
+		{"fuzziness", request.Fuzziness != ""},
+		{"profile", request.Profile != ""},
+		{"facets", request.Facets},
+		{"highlight", request.Highlight},
+		{"explain", request.Explain},
+	} {
+		if option.found {
+			return service.SearchResult{}, newParameterError(util.ErrCodeUnsupportedParameter, option.field, fmt.Sprintf("'%s' is only supported when searching concepts by text", option.field))
+		}
+	}
+	if len(request.Types) == 0 {
+		return service.SearchResult{}, newParameterError(util.ErrCodeMissingParameter, "types", "either a text to search for or the type of the concepts to list is required")
+	}
+	if len(request.Types) > 1 {
+		return service.SearchResult{}, newParameterError(util.ErrCodeInvalidParameter, "types", "only a single type is supported when listing concepts by type")
+	}
+
+	opts := service.ListOptions{
+		SearchAllAuthorities: request.SearchAllAuthorities,
+		IncludeDeprecated:    request.Filters.IncludeDeprecated,
+		Authorities:          request.Authorities,
+		Sort:                 request.Sort,
+		Fields:               request.Fields,
+	}
+	if request.Filters.ModifiedSince != "" {
+		modifiedSince, err := time.Parse(time.RFC3339, request.Filters.ModifiedSince)
+		if err != nil {
+			return service.SearchResult{}, newParameterError(util.ErrCodeInvalidParameter, "filters.modifiedSince", fmt.Sprintf("'%s' is not a valid RFC3339 value for 'filters.modifiedSince'", request.Filters.ModifiedSince))
+		}
+		opts.ModifiedSince = modifiedSince
+	}
+	if request.Paging != nil {
+		opts.From = request.Paging.From
+		opts.Size = request.Paging.Size
+		opts.SearchAfter = request.Paging.SearchAfter
+	}
+	return h.service.FindAllConceptsByType(req.Context(), request.Types[0], opts)
+}
+
+// v2RequestError names the field of the v2 search request at fault in an InputError of the search service
+func v2RequestError(err error) error {
+	if inputErr, ok := err.(util.InputError); ok {
+		if field, found := v2RequestFields[inputErr.Parameter()]; found {
+			return inputErr.ForParameter(field)
+		}
+	}
+	return err
+}
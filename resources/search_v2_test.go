@@ -0,0 +1,206 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type v2TestResponse struct {
+	Concepts    []map[string]interface{} `json:"concepts"`
+	Total       int64                    `json:"total"`
+	Took        int64                    `json:"took"`
+	Limit       int                      `json:"limit"`
+	Profile     string                   `json:"profile"`
+	SearchAfter string                   `json:"searchAfter"`
+	Facets      service.Facets           `json:"facets"`
+}
+
+func TestSearchConceptsV2ByText(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(`{
+		"text": "pippo",
+		"types": ["http://www.ft.com/ontology/person/Person"],
+		"authorities": ["TME"],
+		"searchAllAuthorities": true,
+		"filters": {"includeDeprecated": true},
+		"fuzziness": "AUTO",
+		"profile": "people",
+		"facets": true,
+		"highlight": true
+	}`))
+	svc := &mockConceptSearchService{}
+	facets := service.Facets{"type": []service.FacetBucket{{Value: "http://www.ft.com/ontology/person/Person", Count: 2}}}
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, service.SearchOptions{
+		SearchAllAuthorities: true,
+		IncludeDeprecated:    true,
+		Authorities:          []string{"TME"},
+		IncludeFacets:        true,
+		Fuzziness:            "AUTO",
+		Highlight:            true,
+		Profile:              "people",
+	}).Return(service.SearchResult{Concepts: dummyConcepts(), Total: 2, Took: 12, Index: "concepts", Limit: 10, Profile: "people", Facets: facets}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content-type")
+	var response v2TestResponse
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&response))
+	require.Len(t, response.Concepts, 2)
+	assert.Equal(t, "Test Genre 1", response.Concepts[0]["prefLabel"])
+	assert.Equal(t, "http://www.ft.com/ontology/Genre", response.Concepts[0]["type"])
+	assert.Equal(t, int64(2), response.Total)
+	assert.Equal(t, int64(12), response.Took)
+	assert.Equal(t, 10, response.Limit)
+	assert.Equal(t, "people", response.Profile)
+	assert.Equal(t, facets, response.Facets)
+	svc.AssertExpectations(t)
+}
+
+func TestSearchConceptsV2ByTextWithBoost(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(`{"text": "pippo", "types": ["http://www.ft.com/ontology/person/Person"], "boost": "authors"}`))
+	svc := &mockConceptSearchService{}
+	svc.On("SearchConceptByTextAndTypesWithBoost", "pippo", []string{"http://www.ft.com/ontology/person/Person"}, "authors", service.SearchOptions{}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchConceptsV2ByType(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(`{
+		"types": ["http://www.ft.com/ontology/Genre"],
+		"authorities": ["TME"],
+		"filters": {"modifiedSince": "2019-05-13T10:00:00Z"},
+		"paging": {"size": 2, "searchAfter": "WyJhbmFseXNpcyIsIjEiXQ"},
+		"sort": "popularity"
+	}`))
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{
+		Authorities:   []string{"TME"},
+		ModifiedSince: time.Date(2019, 5, 13, 10, 0, 0, 0, time.UTC),
+		Size:          2,
+		SearchAfter:   "WyJhbmFseXNpcyIsIjEiXQ",
+		Sort:          "popularity",
+	}).Return(service.SearchResult{Concepts: dummyConcepts(), Total: 7, Limit: 2, SearchAfter: "WyJ0ZXN0IGdlbnJlIDIiLCIyIl0"}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	var response v2TestResponse
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&response))
+	assert.Len(t, response.Concepts, 2)
+	assert.Equal(t, int64(7), response.Total)
+	assert.Equal(t, 2, response.Limit)
+	assert.Equal(t, "WyJ0ZXN0IGdlbnJlIDIiLCIyIl0", response.SearchAfter)
+	assert.Nil(t, response.Facets, "facets should only be returned when requested")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchConceptsV2NoConcepts(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(`{"types": ["http://www.ft.com/ontology/Genre"]}`))
+	svc := &mockConceptSearchService{}
+	svc.On("FindAllConceptsByType", "http://www.ft.com/ontology/Genre", service.ListOptions{}).Return(service.SearchResult{Concepts: []service.Concept{}}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	var response map[string]interface{}
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&response))
+	assert.Equal(t, []interface{}{}, response["concepts"], "no concepts should be an empty array, rather than null")
+}
+
+func TestSearchConceptsV2WithFields(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(`{"text": "pippo", "types": ["http://www.ft.com/ontology/Genre"], "fields": ["id", "prefLabel"], "highlight": true}`))
+	svc := &mockConceptSearchService{}
+	concepts := dummyConcepts()
+	concepts[0].Highlight = service.Highlight{"prefLabel": {"Test <em>Genre</em> 1"}}
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/Genre"}, service.SearchOptions{Highlight: true, Fields: []string{"id", "prefLabel"}}).Return(service.SearchResult{Concepts: concepts}, nil)
+
+	actual := doHttpCall(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	var response v2TestResponse
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&response))
+	require.Len(t, response.Concepts, 2)
+	assert.Equal(t, map[string]interface{}{
+		"id":        "http://api.ft.com/things/1",
+		"prefLabel": "Test Genre 1",
+		"highlight": map[string]interface{}{"prefLabel": []interface{}{"Test <em>Genre</em> 1"}},
+	}, response.Concepts[0], "the highlight should be kept along with the requested fields")
+	assert.Equal(t, map[string]interface{}{"id": "http://api.ft.com/things/2", "prefLabel": "Test Genre 2"}, response.Concepts[1])
+	svc.AssertExpectations(t)
+}
+
+func TestSearchConceptsV2WithExplain(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(`{"text": "pippo", "types": ["http://www.ft.com/ontology/Genre"], "explain": true}`))
+	svc := &mockConceptSearchService{}
+	svc.On("SearchConceptByTextAndTypes", "pippo", []string{"http://www.ft.com/ontology/Genre"}, service.SearchOptions{Explain: true}).Return(service.SearchResult{Concepts: dummyConcepts()}, nil)
+
+	actual := doHttpCallWithExplain(svc, req)
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "http status")
+	svc.AssertExpectations(t)
+}
+
+func TestSearchConceptsV2ErrorResponses(t *testing.T) {
+	text := `{"text": "lucy", "types": ["http://www.ft.com/ontology/Genre"]}`
+	testCases := []struct {
+		name      string
+		body      string
+		err       error
+		status    int
+		code      string
+		parameter string
+	}{
+		{"invalid body", `{"text": "lucy"`, nil, http.StatusBadRequest, "invalid_body", ""},
+		{"unknown field", `{"q": "lucy", "types": ["http://www.ft.com/ontology/Genre"]}`, nil, http.StatusBadRequest, "invalid_body", ""},
+		{"invalid field type", `{"text": "lucy", "types": "http://www.ft.com/ontology/Genre"}`, nil, http.StatusBadRequest, "invalid_body", ""},
+		{"unknown concept field", `{"text": "lucy", "types": ["http://www.ft.com/ontology/Genre"], "fields": ["id", "colour"]}`, nil, http.StatusBadRequest, "invalid_parameter", "fields"},
+		{"disabled feature", `{"text": "lucy", "types": ["http://www.ft.com/ontology/Genre"], "explain": true}`, nil, http.StatusBadRequest, "feature_disabled", "explain"},
+		{"search with paging", `{"text": "lucy", "types": ["http://www.ft.com/ontology/Genre"], "paging": {"size": 10}}`, nil, http.StatusBadRequest, "unsupported_parameter", "paging"},
+		{"search modified since", `{"text": "lucy", "types": ["http://www.ft.com/ontology/Genre"], "filters": {"modifiedSince": "2019-05-13T10:00:00Z"}}`, nil, http.StatusBadRequest, "unsupported_parameter", "filters.modifiedSince"},
+		{"search with sort", `{"text": "lucy", "types": ["http://www.ft.com/ontology/Genre"], "sort": "popularity"}`, nil, http.StatusBadRequest, "unsupported_parameter", "sort"},
+		{"listing with fuzziness", `{"types": ["http://www.ft.com/ontology/Genre"], "fuzziness": "1"}`, nil, http.StatusBadRequest, "unsupported_parameter", "fuzziness"},
+		{"listing with facets", `{"types": ["http://www.ft.com/ontology/Genre"], "facets": true}`, nil, http.StatusBadRequest, "unsupported_parameter", "facets"},
+		{"listing without type", `{}`, nil, http.StatusBadRequest, "missing_parameter", "types"},
+		{"listing multiple types", `{"types": ["http://www.ft.com/ontology/Genre", "http://www.ft.com/ontology/Topic"]}`, nil, http.StatusBadRequest, "invalid_parameter", "types"},
+		{"invalid modified since", `{"types": ["http://www.ft.com/ontology/Genre"], "filters": {"modifiedSince": "yesterday"}}`, nil, http.StatusBadRequest, "invalid_parameter", "filters.modifiedSince"},
+		{"service input error", text, util.ErrNoConceptTypeParameter, http.StatusBadRequest, "missing_parameter", "types"},
+		{"service paging error", text, util.NewParameterError(util.ErrCodeInvalidParameter, "search_after", "invalid search_after parameter"), http.StatusBadRequest, "invalid_parameter", "paging.searchAfter"},
+		{"service profile error", text, util.NewParameterError(util.ErrCodeInvalidParameter, "profile", "unknown ranking profile"), http.StatusBadRequest, "invalid_parameter", "profile"},
+		{"no elasticsearch", text, util.ErrNoElasticClient, http.StatusServiceUnavailable, "search_unavailable", ""},
+		{"query timeout", text, util.ErrQueryTimeout, http.StatusGatewayTimeout, "search_timeout", ""},
+		{"search failure", text, errors.New("Test error"), http.StatusInternalServerError, "search_failed", ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest("POST", "/v2/concepts/search", strings.NewReader(tc.body))
+		req.Header.Set("X-Request-Id", "tid_test")
+		svc := &mockConceptSearchService{}
+		if tc.err != nil {
+			svc.On("SearchConceptByTextAndTypes", "lucy", []string{"http://www.ft.com/ontology/Genre"}, service.SearchOptions{}).Return(service.SearchResult{}, tc.err)
+		}
+
+		actual := doHttpCall(svc, req)
+
+		assert.Equal(t, tc.status, actual.StatusCode, tc.name)
+		assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), tc.name)
+		respObject := unmarshallResponseMessage(t, actual)
+		assert.Equal(t, tc.code, respObject["code"], tc.name)
+		assert.Equal(t, tc.parameter, respObject["parameter"], tc.name)
+		assert.NotEmpty(t, respObject["message"], tc.name)
+		assert.Equal(t, "tid_test", respObject["transaction_id"], tc.name)
+		svc.AssertExpectations(t)
+	}
+}
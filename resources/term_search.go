@@ -0,0 +1,256 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/Financial-Times/transactionid-utils-go"
+	log "github.com/sirupsen/logrus"
+)
+
+var errNoConceptsFound = errors.New("no concepts found")
+
+// termSearchCriteria is the payload of POST /concept/search, which holds either a term or best match terms
+type termSearchCriteria struct {
+	Term           *string  `json:"term"`
+	BestMatchTerms []string `json:"bestMatchTerms"`
+	ConceptTypes   []string `json:"conceptTypes"`
+	Authorities    []string `json:"authorities"`
+	BoostType      string   `json:"boost"`
+	FilterType     string   `json:"filter"`
+	Fuzzy          bool     `json:"fuzzy"`
+	Fuzziness      string   `json:"fuzziness"`
+}
+
+// termSearchConcept is a concept found by POST /concept/search, which keeps the shape of the concepts stored in
+// Elasticsearch rather than the one of the service.Concept
+type termSearchConcept struct {
+	ID                     string                    `json:"id"`
+	APIUrl                 string                    `json:"apiUrl"`
+	PrefLabel              string                    `json:"prefLabel"`
+	Types                  []string                  `json:"types"`
+	DirectType             string                    `json:"directType"`
+	Aliases                []string                  `json:"aliases,omitempty"`
+	Score                  float64                   `json:"score,omitempty"`
+	IsFTAuthor             string                    `json:"isFTAuthor,omitempty"`
+	ScopeNote              string                    `json:"scopeNote,omitempty"`
+	IsDeprecated           bool                      `json:"isDeprecated,omitempty"`
+	CountryCode            string                    `json:"countryCode,omitempty"`
+	CountryOfIncorporation string                    `json:"countryOfIncorporation,omitempty"`
+	LastModified           string                    `json:"lastModified,omitempty"`
+	PublishReference       string                    `json:"publishReference,omitempty"`
+	Metrics                *service.ConceptMetrics   `json:"metrics,omitempty"`
+	Highlight              service.Highlight         `json:"highlight,omitempty"`
+	Explanation            *service.ScoreExplanation `json:"explanation,omitempty"`
+}
+
+type termSearchResult struct {
+	Results []json.RawMessage `json:"results"`
+	Facets  service.Facets    `json:"facets,omitempty"`
+}
+
+// SearchConceptsByTerm serves POST /concept/search, which finds the concepts matching the term of its payload, or the
+// best matching concept of each of its best match terms.
+func (h *Handler) SearchConceptsByTerm(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var criteria termSearchCriteria
+	if err := json.NewDecoder(req.Body).Decode(&criteria); err != nil {
+		writeServiceError(w, req, util.NewParameterErrorf(util.ErrCodeInvalidBody, "", "invalid request body, it should be a JSON object holding the search criteria: %v", err))
+		return
+	}
+
+	fields, _, err := getFieldsParameter(req, termSearchFields)
+	includeMetrics := isIncluded(req, "include_metrics")
+	if includeMetrics && len(fields) > 0 && !contains(fields, "metrics") {
+		fields = append(fields, "metrics")
+	}
+	if err == nil {
+		err = validateTermSearchCriteria(criteria)
+	}
+	fuzziness := ""
+	if err == nil {
+		fuzziness, err = util.ResolveFuzziness(criteria.Fuzzy, criteria.Fuzziness)
+	}
+	if err == nil && isIncluded(req, "explain") && !h.explainEnabled {
+		err = newParameterError(util.ErrCodeFeatureDisabled, "explain", "score explanations are not enabled")
+	}
+	if err != nil {
+		writeServiceError(w, req, err)
+		return
+	}
+
+	opts := service.TermSearchOptions{
+		SearchAllAuthorities: isIncluded(req, "searchAllAuthorities"),
+		IncludeDeprecated:    isIncluded(req, "include_deprecated"),
+		Authorities:          criteria.Authorities,
+		ConceptTypes:         criteria.ConceptTypes,
+		Boost:                criteria.BoostType,
+		Filter:               criteria.FilterType,
+		Fuzziness:            fuzziness,
+		IncludeFacets:        isIncluded(req, "include_facets"),
+		Highlight:            isIncluded(req, "highlight"),
+		Explain:              isIncluded(req, "explain"),
+		Fields:               fields,
+	}
+	format := termSearchFormat{
+		includeScore:    isIncluded(req, "include_score") || contains(fields, "score"),
+		includeFTAuthor: isFieldIncluded(req, "authors") || contains(fields, "isFTAuthor"),
+		includeMetrics:  includeMetrics || contains(fields, "metrics"),
+		fields:          fields,
+	}
+	transactionID := transactionidutils.GetTransactionIDFromRequest(req)
+
+	if criteria.Term != nil {
+		log.Infof("Performing concept search for term=%v, transaction_id=%v", *criteria.Term, transactionID)
+		h.searchConceptsByTerm(w, req, *criteria.Term, opts, format)
+	} else {
+		log.Infof("Performing concept search for bestMatchTerms=%v, transaction_id=%v", strings.Join(criteria.BestMatchTerms, ", "), transactionID)
+		h.findBestMatchingConcepts(w, req, criteria.BestMatchTerms, opts, format)
+	}
+}
+
+func (h *Handler) searchConceptsByTerm(w http.ResponseWriter, req *http.Request, term string, opts service.TermSearchOptions, format termSearchFormat) {
+	result, err := h.service.SearchConceptsByTerm(req.Context(), term, opts)
+	if err != nil {
+		writeTermSearchError(w, req, err)
+		return
+	}
+	if len(result.Concepts) == 0 {
+		util.WriteError(w, req, http.StatusNotFound, util.ErrCodeNotFound, errNoConceptsFound)
+		return
+	}
+
+	response := termSearchResult{Facets: result.Facets}
+	for _, c := range result.Concepts {
+		concept, err := format.encode(c)
+		if err != nil {
+			util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeInternalError, err)
+			return
+		}
+		response.Results = append(response.Results, concept)
+	}
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) findBestMatchingConcepts(w http.ResponseWriter, req *http.Request, terms []string, opts service.TermSearchOptions, format termSearchFormat) {
+	matches, err := h.service.FindBestMatchingConcepts(req.Context(), terms, opts)
+	if err != nil {
+		writeTermSearchError(w, req, err)
+		return
+	}
+
+	found := false
+	response := make(map[string][]json.RawMessage)
+	for term, match := range matches {
+		response[term] = []json.RawMessage{}
+		if match != nil {
+			concept, err := format.encode(*match)
+			if err != nil {
+				util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeInternalError, err)
+				return
+			}
+			response[term] = append(response[term], concept)
+			found = true
+		}
+	}
+	if !found {
+		util.WriteError(w, req, http.StatusNotFound, util.ErrCodeNotFound, errNoConceptsFound)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// termSearchFormat tells which of the optional fields of the concepts are returned by POST /concept/search, and which
+// fields are returned at all when some are picked
+type termSearchFormat struct {
+	includeScore    bool
+	includeFTAuthor bool
+	includeMetrics  bool
+	fields          []string
+}
+
+func (f termSearchFormat) encode(c service.ScoredConcept) (json.RawMessage, error) {
+	return pickFields(f.concept(c), f.fields)
+}
+
+func (f termSearchFormat) concept(c service.ScoredConcept) termSearchConcept {
+	concept := termSearchConcept{
+		ID:                     c.Id,
+		APIUrl:                 c.ApiUrl,
+		PrefLabel:              c.PrefLabel,
+		Types:                  c.Types,
+		DirectType:             c.DirectType,
+		Aliases:                c.Aliases,
+		ScopeNote:              c.ScopeNote,
+		IsDeprecated:           c.IsDeprecated,
+		CountryCode:            c.CountryCode,
+		CountryOfIncorporation: c.CountryOfIncorporation,
+		LastModified:           c.LastModified,
+		PublishReference:       c.PublishReference,
+		Highlight:              c.Highlight,
+		Explanation:            c.Explanation,
+	}
+	if f.includeScore {
+		concept.Score = c.Score
+	}
+	if f.includeFTAuthor && c.IsFTAuthor != nil {
+		concept.IsFTAuthor = *c.IsFTAuthor
+	}
+	if f.includeMetrics {
+		concept.Metrics = c.Metrics
+	}
+	return concept
+}
+
+func validateTermSearchCriteria(criteria termSearchCriteria) error {
+	if criteria.Term == nil && len(criteria.BestMatchTerms) == 0 {
+		return newParameterError(util.ErrCodeMissingParameter, "term", "the search criteria should hold either a 'term' or 'bestMatchTerms'")
+	}
+	if criteria.Term != nil && len(criteria.BestMatchTerms) > 0 {
+		return newParameterError(util.ErrCodeUnsupportedParameter, "bestMatchTerms", "the search criteria should hold either a 'term' or 'bestMatchTerms', not both")
+	}
+	if len(criteria.BestMatchTerms) > 0 && (criteria.Fuzzy || criteria.Fuzziness != "") {
+		return newParameterError(util.ErrCodeUnsupportedParameter, criteria.fuzzyField(), "fuzzy matching is only supported for 'term' searches")
+	}
+	return nil
+}
+
+// fuzzyField returns the fuzzy matching field of the search criteria, for its errors to name
+func (criteria termSearchCriteria) fuzzyField() string {
+	if criteria.Fuzzy {
+		return "fuzzy"
+	}
+	return "fuzziness"
+}
+
+// writeTermSearchError answers a failed POST /concept/search, which has always answered with a 500 when Elasticsearch
+// cannot be reached, and names the fields of its payload rather than the matching parameters of GET /concepts
+func writeTermSearchError(w http.ResponseWriter, req *http.Request, err error) {
+	if inputErr, ok := err.(util.InputError); ok && inputErr.Parameter() == "type" {
+		err = inputErr.ForParameter("conceptTypes")
+	}
+	if err == util.ErrNoElasticClient {
+		util.WriteError(w, req, http.StatusInternalServerError, util.ErrCodeSearchUnavailable, err)
+		return
+	}
+	writeServiceError(w, req, err)
+}
+
+// isIncluded reads the optional flags of POST /concept/search, which have always been false unless set to a valid true
+func isIncluded(req *http.Request, param string) bool {
+	included, _, err := util.GetBoolQueryParameter(req, param, false)
+	return err == nil && included
+}
+
+func isFieldIncluded(req *http.Request, field string) bool {
+	fields, _ := util.GetMultipleValueQueryParameter(req, "include_field")
+	return contains(fields, field)
+}
@@ -1,4 +1,4 @@
-package main
+package resources
 
 import (
 	"context"
@@ -10,27 +10,31 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"testing"
 
 	"log"
 
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/Financial-Times/concept-search-api/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/olivere/elastic.v5"
 )
 
-func TestConceptFinder(t *testing.T) {
+func TestSearchConceptsByTerm(t *testing.T) {
 
 	testCases := []struct {
-		client        esClient
+		client        service.ConceptRepository
 		returnCode    int
 		requestURL    string
 		requestBody   string
 		expectedUUIDs []string
 		expectedScore []float64
-		assertFields  map[string]func(concept)
+		assertFields  map[string]func(termSearchConcept)
 	}{
 		{
+			client:      service.NewEsConceptRepository(0),
 			returnCode:  http.StatusInternalServerError,
 			requestURL:  defaultRequestURL,
 			requestBody: validRequestBody,
@@ -55,13 +59,15 @@ func TestConceptFinder(t *testing.T) {
 			requestURL:    defaultRequestURL,
 			requestBody:   validRequestBody,
 			expectedUUIDs: []string{"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9", "6084734d-f4c2-3375-b298-dbbc6c00a680"},
-			assertFields: map[string]func(concept){
-				"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9": func(c concept) {
+			assertFields: map[string]func(termSearchConcept){
+				"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9": func(c termSearchConcept) {
 					assert.Equal(t, "Foobar SpA", c.PrefLabel)
 					assert.Equal(t, "", c.ScopeNote)
 					assert.Equal(t, "http://www.ft.com/ontology/company/PublicCompany", c.DirectType)
 					assert.Equal(t, "CA", c.CountryCode)
 					assert.Equal(t, "US", c.CountryOfIncorporation)
+					assert.Equal(t, "2019-05-13T10:12:02.123Z", c.LastModified)
+					assert.Equal(t, "tid_foobar", c.PublishReference)
 				}},
 		},
 		{
@@ -100,8 +106,8 @@ func TestConceptFinder(t *testing.T) {
 			requestURL:    requestURLWithAllAuthorities,
 			requestBody:   validRequestBody,
 			expectedUUIDs: []string{"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9", "6084734d-f4c2-3375-b298-dbbc6c00a680"},
-			assertFields: map[string]func(concept){
-				"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9": func(c concept) {
+			assertFields: map[string]func(termSearchConcept){
+				"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9": func(c termSearchConcept) {
 					assert.Equal(t, "Foobar SpA", c.PrefLabel)
 					assert.Equal(t, "", c.ScopeNote)
 					assert.Equal(t, "http://www.ft.com/ontology/company/PublicCompany", c.DirectType)
@@ -131,27 +137,37 @@ func TestConceptFinder(t *testing.T) {
 			requestURL:  defaultRequestURL,
 			requestBody: missingTermRequestBody,
 		},
+		{
+			client:      failClient{},
+			returnCode:  http.StatusBadRequest,
+			requestURL:  defaultRequestURL,
+			requestBody: `{"term":"Foobar", "fuzziness":"3"}`,
+		},
+		{
+			client: mockClient{
+				queryResponse: validResponse,
+			},
+			returnCode:    http.StatusOK,
+			requestURL:    defaultRequestURL,
+			requestBody:   `{"term":"Fobar", "fuzzy":true}`,
+			expectedUUIDs: []string{"9a0dd8b8-2ae4-34ca-8639-cfef69711eb9", "6084734d-f4c2-3375-b298-dbbc6c00a680"},
+		},
 	}
 
 	for _, testCase := range testCases {
-		conceptFinder := &esConceptFinder{
-			defaultIndex:      "concept",
-			searchResultLimit: 50,
-			lockClient:        &sync.RWMutex{},
-		}
-		conceptFinder.client = testCase.client
+		handler := newTermSearchHandler(testCase.client, "concept", false)
 
 		req, _ := http.NewRequest("POST", testCase.requestURL, strings.NewReader(testCase.requestBody))
 		w := httptest.NewRecorder()
 
-		conceptFinder.FindConcept(w, req)
+		handler.SearchConceptsByTerm(w, req)
 
 		assert.Equal(t, testCase.returnCode, w.Code, "Expected return code %d but got %d", testCase.returnCode, w.Code)
 		if testCase.returnCode != http.StatusOK {
 			continue
 		}
 
-		var searchResults searchResult
+		var searchResults termSearchTestResult
 		err := json.Unmarshal(w.Body.Bytes(), &searchResults)
 		assert.Equal(t, nil, err)
 		assert.Equal(t, len(testCase.expectedUUIDs), len(searchResults.Results))
@@ -179,16 +195,235 @@ func TestConceptFinder(t *testing.T) {
 	}
 }
 
-func TestConceptFinderForBestMatch(t *testing.T) {
+func TestSearchConceptsByTermWithFacets(t *testing.T) {
+	handler := newTermSearchHandler(mockClient{queryResponse: validResponseWithFacets}, "concept", false)
+
+	req, _ := http.NewRequest("POST", requestURLWithFacets, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var searchResults termSearchTestResult
+	err := json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Len(t, searchResults.Results, 1)
+	assert.Equal(t, []service.FacetBucket{
+		{Value: "http://www.ft.com/ontology/company/PublicCompany", Count: 12},
+		{Value: "http://www.ft.com/ontology/organisation/Organisation", Count: 4},
+	}, searchResults.Facets["type"])
+	assert.Equal(t, []service.FacetBucket{{Value: "false", Count: 16}}, searchResults.Facets["isDeprecated"])
+	assert.Equal(t, []service.FacetBucket{}, searchResults.Facets["isFTAuthor"])
+
+	req, _ = http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.NotContains(t, response, "facets", "facets should only be returned when requested")
+}
+
+func TestSearchConceptsByTermWithHighlight(t *testing.T) {
+	handler := newTermSearchHandler(mockClient{queryResponse: validResponseWithHighlight}, "concept", false)
+
+	req, _ := http.NewRequest("POST", requestURLWithHighlight, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var searchResults termSearchTestResult
+	err := json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Len(t, searchResults.Results, 1)
+	assert.Equal(t, service.Highlight{"aliases": {"<em>Foobar</em> Holdings"}}, searchResults.Results[0].Highlight)
+
+	req, _ = http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	searchResults = termSearchTestResult{}
+	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Nil(t, searchResults.Results[0].Highlight, "highlights should only be returned when requested")
+}
+
+func TestSearchConceptsByTermWithExplain(t *testing.T) {
+	handler := newTermSearchHandler(mockClient{queryResponse: validResponseWithExplanation}, "concept", true)
+
+	req, _ := http.NewRequest("POST", requestURLWithExplain, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var searchResults termSearchTestResult
+	err := json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Len(t, searchResults.Results, 1)
+	explanation := searchResults.Results[0].Explanation
+	if assert.NotNil(t, explanation) {
+		assert.Equal(t, 6.5, explanation.Score)
+		assert.Equal(t, map[string]float64{"textMatch": 4, "prefLabelExactMatch": 9}, explanation.Contributions)
+		assert.Equal(t, "product of:", explanation.Details.Description)
+	}
+
+	req, _ = http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	searchResults = termSearchTestResult{}
+	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Nil(t, searchResults.Results[0].Explanation, "explanations should only be returned when requested")
+}
+
+func TestSearchConceptsByTermWithFields(t *testing.T) {
+	handler := newTermSearchHandler(mockClient{queryResponse: validResponse}, "concept", false)
+
+	req, _ := http.NewRequest("POST", requestURLWithFields, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var searchResults struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	require.NotEmpty(t, searchResults.Results)
+	assert.Equal(t, map[string]interface{}{
+		"id":        "http://api.ft.com/things/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+		"prefLabel": "Foobar SpA",
+		"score":     9.992676,
+	}, searchResults.Results[0], "only the requested fields should be returned")
+}
+
+func TestSearchConceptsByTermWithMetrics(t *testing.T) {
+	handler := newTermSearchHandler(mockClient{queryResponse: validResponseWithMetrics}, "concept", false)
+
+	req, _ := http.NewRequest("POST", requestURLWithMetrics, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var searchResults termSearchTestResult
+	err := json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Len(t, searchResults.Results, 1)
+	assert.Equal(t, &service.ConceptMetrics{AnnotationsCount: 1250, PrevWeekAnnotationsCount: 31}, searchResults.Results[0].Metrics)
+
+	req, _ = http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	searchResults = termSearchTestResult{}
+	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Nil(t, searchResults.Results[0].Metrics, "metrics should only be returned when requested")
+}
+
+func TestSearchConceptsByTermErrorResponses(t *testing.T) {
+	testCases := []struct {
+		name        string
+		client      service.ConceptRepository
+		requestURL  string
+		requestBody string
+		status      int
+		code        string
+		parameter   string
+	}{
+		{"invalid body", failClient{}, defaultRequestURL, invalidRequestBody, http.StatusBadRequest, "invalid_body", ""},
+		{"missing term", failClient{}, defaultRequestURL, missingTermRequestBody, http.StatusBadRequest, "missing_parameter", "term"},
+		{"term and best match terms", failClient{}, defaultRequestURL, `{"term":"Foobar", "bestMatchTerms":["testTerm"]}`, http.StatusBadRequest, "unsupported_parameter", "bestMatchTerms"},
+		{"invalid fuzziness", failClient{}, defaultRequestURL, `{"term":"Foobar", "fuzziness":"3"}`, http.StatusBadRequest, "invalid_parameter", "fuzziness"},
+		{"best match facets", failClient{}, requestURLWithFacets, `{"bestMatchTerms":["testTerm"]}`, http.StatusBadRequest, "unsupported_parameter", "include_facets"},
+		{"unknown field", failClient{}, "http://nothing/at/all?fields=id,type", validRequestBody, http.StatusBadRequest, "invalid_parameter", "fields"},
+		{"explain disabled", failClient{}, requestURLWithExplain, validRequestBody, http.StatusBadRequest, "feature_disabled", "explain"},
+		{"invalid concept type", failClient{}, defaultRequestURL, `{"bestMatchTerms":["testTerm"], "conceptTypes": ["http://www.ft.com/ontology/organisation/NotExisting"]}`, http.StatusBadRequest, "invalid_parameter", "conceptTypes"},
+		{"invalid boost", failClient{}, defaultRequestURL, `{"bestMatchTerms":["testTerm"], "boost":"wrong_boost"}`, http.StatusBadRequest, "invalid_parameter", "boost"},
+		{"invalid filter", failClient{}, defaultRequestURL, `{"bestMatchTerms":["testTerm"], "filter":"wrong_filter"}`, http.StatusBadRequest, "invalid_parameter", "filter"},
+		{"no results", mockClient{queryResponse: emptyResponse}, defaultRequestURL, validRequestBody, http.StatusNotFound, "not_found", ""},
+		{"search failure", failClient{}, defaultRequestURL, validRequestBody, http.StatusInternalServerError, "search_failed", ""},
+		{"best match search failure", failClient{}, defaultRequestURL, `{"bestMatchTerms":["testTerm"]}`, http.StatusInternalServerError, "search_failed", ""},
+		{"no elasticsearch", service.NewEsConceptRepository(0), defaultRequestURL, validRequestBody, http.StatusInternalServerError, "search_unavailable", ""},
+	}
+
+	for _, tc := range testCases {
+		handler := newTermSearchHandler(tc.client, "concept", false)
+		req, _ := http.NewRequest("POST", tc.requestURL, strings.NewReader(tc.requestBody))
+		req.Header.Set("X-Request-Id", "tid_test")
+		w := httptest.NewRecorder()
+
+		handler.SearchConceptsByTerm(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.name)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), tc.name)
+		var response util.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), tc.name)
+		assert.Equal(t, tc.code, response.Code, tc.name)
+		assert.Equal(t, tc.parameter, response.Parameter, tc.name)
+		assert.NotEmpty(t, response.Message, tc.name)
+		assert.Equal(t, "tid_test", response.TransactionID, tc.name)
+	}
+}
+
+func TestSearchConceptsByTermWithExplainDisabled(t *testing.T) {
+	handler := newTermSearchHandler(mockClient{queryResponse: validResponseWithExplanation}, "concept", false)
+
+	for _, body := range []string{validRequestBody, `{"bestMatchTerms":["testTerm"]}`} {
+		req, _ := http.NewRequest("POST", requestURLWithExplain, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.SearchConceptsByTerm(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestSearchConceptsByTermCancelledRequest(t *testing.T) {
+	repository := servicetest.NewInMemoryConceptRepository().Add("concept", "people", service.EsConceptModel{Id: "http://api.ft.com/things/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9", PrefLabel: "Foobar"})
+	handler := newTermSearchHandler(repository, "concept", false)
+
+	req, _ := http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ = http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req.WithContext(ctx))
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "the cancellation of the request should reach the repository")
+}
+
+func TestSearchConceptsByTermQueryTimeout(t *testing.T) {
+	repository := servicetest.NewInMemoryConceptRepository().FailWith(util.ErrQueryTimeout)
+	handler := newTermSearchHandler(repository, "concept", false)
+
+	req, _ := http.NewRequest("POST", defaultRequestURL, strings.NewReader(validRequestBody))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	req, _ = http.NewRequest("POST", defaultRequestURL, strings.NewReader(`{"bestMatchTerms":["testTerm"]}`))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestSearchConceptsByTermForBestMatch(t *testing.T) {
 
 	testCases := []struct {
 		testName            string
-		client              esClient
+		client              service.ConceptRepository
 		returnCode          int
 		requestURL          string
 		requestBody         string
 		expectedUUIDs       map[string][]string
-		extraAssertionLogic func(t *testing.T, searchResults map[string][]concept)
+		extraAssertionLogic func(t *testing.T, searchResults map[string][]termSearchConcept)
 	}{
 		{
 			testName:    "TooMuchDataInPayload",
@@ -218,6 +453,20 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 			requestURL:  defaultRequestURL,
 			requestBody: `{"bestMatchTerms":["testTerm"], "boost":"wrong_boost","conceptTypes": ["http://www.ft.com/ontology/organisation/Organisation"]}`,
 		},
+		{
+			testName:    "FuzzyNotSupported",
+			client:      mockClient{},
+			returnCode:  http.StatusBadRequest,
+			requestURL:  defaultRequestURL,
+			requestBody: `{"bestMatchTerms":["testTerm"], "fuzzy": true}`,
+		},
+		{
+			testName:    "FacetsNotSupported",
+			client:      mockClient{},
+			returnCode:  http.StatusBadRequest,
+			requestURL:  requestURLWithFacets,
+			requestBody: `{"bestMatchTerms":["testTerm"]}`,
+		},
 		{
 			testName:    "WrongFilterTypeCombination",
 			client:      mockClient{},
@@ -258,7 +507,7 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 					"9332270e-f959-3f55-9153-d30acd0d0a51",
 				},
 			},
-			extraAssertionLogic: func(t *testing.T, searchResults map[string][]concept) {
+			extraAssertionLogic: func(t *testing.T, searchResults map[string][]termSearchConcept) {
 				for _, concepts := range searchResults {
 					for _, res := range concepts {
 						_, err := strconv.ParseBool(res.IsFTAuthor)
@@ -267,6 +516,26 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 				}
 			},
 		},
+		{
+			testName: "OkSectionAndSubjectTypes",
+			client: mockClient{
+				queryResponse: validResponseBestMatch,
+			},
+			returnCode:  http.StatusOK,
+			requestURL:  defaultRequestURL,
+			requestBody: `{"bestMatchTerms":["Adam Samson", "Eric Platt", "Michael Hunter"], "conceptTypes": ["http://www.ft.com/ontology/Section", "http://www.ft.com/ontology/Subject"]}`,
+			expectedUUIDs: map[string][]string{
+				"Adam Samson": []string{
+					"f758ef56-c40a-3162-91aa-3e8a3aabc494",
+				},
+				"Eric Platt": []string{
+					"40281396-8369-4699-ae48-1ccc0c931a72",
+				},
+				"Michael Hunter": []string{
+					"9332270e-f959-3f55-9153-d30acd0d0a51",
+				},
+			},
+		},
 		{
 			testName: "OkPeopleTypePartialResults",
 			client: mockClient{
@@ -284,7 +553,7 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 					"9332270e-f959-3f55-9153-d30acd0d0a51",
 				},
 			},
-			extraAssertionLogic: func(t *testing.T, searchResults map[string][]concept) {
+			extraAssertionLogic: func(t *testing.T, searchResults map[string][]termSearchConcept) {
 				for _, concepts := range searchResults {
 					for _, res := range concepts {
 						_, err := strconv.ParseBool(res.IsFTAuthor)
@@ -306,7 +575,7 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 				"Eric Platt":     []string{},
 				"Michael Hunter": []string{},
 			},
-			extraAssertionLogic: func(t *testing.T, searchResults map[string][]concept) {
+			extraAssertionLogic: func(t *testing.T, searchResults map[string][]termSearchConcept) {
 				for _, concepts := range searchResults {
 					for _, res := range concepts {
 						_, err := strconv.ParseBool(res.IsFTAuthor)
@@ -334,7 +603,7 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 					"9332270e-f959-3f55-9153-d30acd0d0a51",
 				},
 			},
-			extraAssertionLogic: func(t *testing.T, searchResults map[string][]concept) {
+			extraAssertionLogic: func(t *testing.T, searchResults map[string][]termSearchConcept) {
 				notAuthorCounter := 0
 				authorCounter := 0
 				for _, concepts := range searchResults {
@@ -355,24 +624,19 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 	}
 
 	for _, testCase := range testCases {
-		conceptFinder := &esConceptFinder{
-			defaultIndex:      "concept",
-			searchResultLimit: 50,
-			lockClient:        &sync.RWMutex{},
-		}
-		conceptFinder.client = testCase.client
+		handler := newTermSearchHandler(testCase.client, "concept", false)
 
 		req, _ := http.NewRequest("POST", testCase.requestURL, strings.NewReader(testCase.requestBody))
 		w := httptest.NewRecorder()
 
-		conceptFinder.FindConcept(w, req)
+		handler.SearchConceptsByTerm(w, req)
 
 		assert.Equal(t, testCase.returnCode, w.Code, "%s -> Expected return code %d but got %d", testCase.testName, testCase.returnCode, w.Code)
 		if testCase.returnCode != http.StatusOK {
 			continue
 		}
 
-		var searchResults map[string][]concept
+		var searchResults map[string][]termSearchConcept
 		err := json.Unmarshal(w.Body.Bytes(), &searchResults)
 		assert.Equal(t, nil, err, "%s -> expected no error", testCase.testName)
 		assert.Equal(t, len(testCase.expectedUUIDs), len(searchResults), "%s -> different no. of results", testCase.testName)
@@ -391,8 +655,6 @@ func TestConceptFinderForBestMatch(t *testing.T) {
 	}
 }
 
-// during concept deprecation story an issue was encountered during calling FindConcept.
-// The filtering was applied in a way that the data was returned even when the query did not match the doc.
 func TestEsQueryScore(t *testing.T) {
 	// create ES client
 	ec, err := elastic.NewClient(
@@ -419,19 +681,74 @@ func TestEsQueryScore(t *testing.T) {
 	// prepare request and trigger this
 	req, _ := http.NewRequest("POST", "http://dummy_host/concepts?include_score=true", strings.NewReader(`{"term": "Anna"}`))
 	w := httptest.NewRecorder()
-	conceptFinder := newConceptFinder(filterScoreTestingIndexName, "", 10)
-	conceptFinder.SetElasticClient(ec)
-	conceptFinder.FindConcept(w, req)
+	repository := service.NewEsConceptRepository(0)
+	repository.SetElasticClient(ec)
+	handler := newTermSearchHandler(repository, filterScoreTestingIndexName, false)
+	handler.SearchConceptsByTerm(w, req)
 
 	// check
 	assert.Equal(t, http.StatusOK, w.Code)
-	var searchResults searchResult
+	var searchResults termSearchTestResult
 	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
 	assert.Equal(t, nil, err)
 	assert.Len(t, searchResults.Results, 1)
 	assert.Equal(t, "Anna Whitwham", searchResults.Results[0].PrefLabel)
 }
 
+func TestEsQueryFuzzy(t *testing.T) {
+	// create ES client
+	ec, err := elastic.NewClient(
+		elastic.SetURL(getElasticSearchTestURL(t)),
+		elastic.SetSniff(false),
+	)
+	assert.NoError(t, err, "expected no error for ES client")
+
+	// cleanup for accuracy
+	ec.DeleteIndex(filterScoreTestingIndexName).Do(context.Background())
+
+	// store testing data
+	for uuid, conceptBody := range filterScoreTestingData {
+		_, e := ec.Index().
+			Index(filterScoreTestingIndexName).
+			Type("people").
+			BodyString(conceptBody).
+			Id(uuid).
+			Do(context.Background())
+		assert.NoError(t, e, "expected no error for ES client")
+	}
+	ec.Refresh(filterScoreTestingIndexName).Do(context.TODO())
+
+	repository := service.NewEsConceptRepository(0)
+	repository.SetElasticClient(ec)
+	handler := newTermSearchHandler(repository, filterScoreTestingIndexName, false)
+
+	// a typo does not match unless fuzzy matching is requested
+	req, _ := http.NewRequest("POST", "http://dummy_host/concepts", strings.NewReader(`{"term": "Whitwam"}`))
+	w := httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("POST", "http://dummy_host/concepts", strings.NewReader(`{"term": "Whitwam", "fuzzy": true}`))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var searchResults termSearchTestResult
+	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Len(t, searchResults.Results, 1)
+	assert.Equal(t, "Anna Whitwham", searchResults.Results[0].PrefLabel)
+
+	// the exact match still comes first
+	req, _ = http.NewRequest("POST", "http://dummy_host/concepts", strings.NewReader(`{"term": "Anna", "fuzziness": "1"}`))
+	w = httptest.NewRecorder()
+	handler.SearchConceptsByTerm(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	searchResults = termSearchTestResult{}
+	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
+	assert.NoError(t, err)
+	assert.Equal(t, "Anna Whitwham", searchResults.Results[0].PrefLabel)
+}
+
 func TestEsBestMatchImpl(t *testing.T) {
 	// create ES client
 	ec, err := elastic.NewClient(
@@ -442,7 +759,7 @@ func TestEsBestMatchImpl(t *testing.T) {
 
 	// cleanup for accuracy
 	ec.DeleteIndex(bestMatchIndexName).Do(context.Background())
-	err = createIndex(ec, "service/test/mapping.json", bestMatchIndexName)
+	err = createIndex(ec, "../service/test/mapping.json", bestMatchIndexName)
 
 	// store testing data
 	for uuid, conceptBody := range bestMatchTestingData {
@@ -468,13 +785,14 @@ func TestEsBestMatchImpl(t *testing.T) {
 			"conceptTypes": ["http://www.ft.com/ontology/person/Person"]
 		}`))
 	w := httptest.NewRecorder()
-	conceptFinder := newConceptFinder(bestMatchIndexName, "", 10)
-	conceptFinder.SetElasticClient(ec)
-	conceptFinder.FindConcept(w, req)
+	repository := service.NewEsConceptRepository(0)
+	repository.SetElasticClient(ec)
+	handler := newTermSearchHandler(repository, bestMatchIndexName, false)
+	handler.SearchConceptsByTerm(w, req)
 
 	// check
 	assert.Equal(t, http.StatusOK, w.Code)
-	var searchResults map[string][]concept
+	var searchResults map[string][]termSearchConcept
 	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
 	assert.Equal(t, nil, err)
 	assert.Len(t, searchResults, 4)
@@ -513,11 +831,11 @@ func TestEsBestMatchImpl(t *testing.T) {
 			"boost": "authors"
 		}`))
 	w = httptest.NewRecorder()
-	conceptFinder.FindConcept(w, req)
+	handler.SearchConceptsByTerm(w, req)
 
 	// check
 	assert.Equal(t, http.StatusOK, w.Code)
-	searchResults = make(map[string][]concept)
+	searchResults = make(map[string][]termSearchConcept)
 	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
 	assert.Equal(t, nil, err)
 	assert.Len(t, searchResults, 4)
@@ -553,11 +871,11 @@ func TestEsBestMatchImpl(t *testing.T) {
 			"filter": "authors"
 		}`))
 	w = httptest.NewRecorder()
-	conceptFinder.FindConcept(w, req)
+	handler.SearchConceptsByTerm(w, req)
 
 	// check
 	assert.Equal(t, http.StatusOK, w.Code)
-	searchResults = make(map[string][]concept)
+	searchResults = make(map[string][]termSearchConcept)
 	err = json.Unmarshal(w.Body.Bytes(), &searchResults)
 	assert.Equal(t, nil, err)
 	assert.Len(t, searchResults, 3)
@@ -578,6 +896,17 @@ func TestEsBestMatchImpl(t *testing.T) {
 	assert.Equal(t, "http://api.ft.com/things/9332270e-f959-3f55-9153-d30acd0d0a51", michaelHunterConcepts[0].ID)
 }
 
+// termSearchTestResult decodes the results of a search by term
+type termSearchTestResult struct {
+	Results []termSearchConcept `json:"results"`
+	Facets  service.Facets      `json:"facets"`
+}
+
+// newTermSearchHandler returns a Handler whose searches by term query the given repository
+func newTermSearchHandler(repository service.ConceptRepository, index string, explainEnabled bool) *Handler {
+	return NewHandler(service.NewConceptSearchService(repository, index, "", 50, 10), CacheMaxAges{}, explainEnabled)
+}
+
 func getElasticSearchTestURL(t *testing.T) string {
 	if testing.Short() {
 		t.Skip("ElasticSearch integration for long tests only.")
@@ -605,15 +934,25 @@ func createIndex(ec *elastic.Client, mappingFile string, indexName string) error
 
 type failClient struct{}
 
-func (tc failClient) query(indexName string, query elastic.Query, resultLimit int) (*elastic.SearchResult, error) {
+func (tc failClient) SetElasticClient(client *elastic.Client) {}
+
+func (tc failClient) Search(ctx context.Context, query service.SearchQuery) (*elastic.SearchResult, error) {
 	return &elastic.SearchResult{}, errors.New("Test ES failure")
 }
 
-func (tc failClient) multiSearchQuery(indexName string, searchRequests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
+func (tc failClient) MultiSearch(ctx context.Context, index string, requests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
 	return &elastic.MultiSearchResult{}, errors.New("Test ES failure")
 }
 
-func (tc failClient) getClusterHealth() (*elastic.ClusterHealthResponse, error) {
+func (tc failClient) Scroll(ctx context.Context, query service.SearchQuery, handle func(*elastic.SearchResult) error) error {
+	return errors.New("Test ES failure")
+}
+
+func (tc failClient) MultiGet(ctx context.Context, index string, ids []string) (*elastic.MgetResponse, error) {
+	return &elastic.MgetResponse{}, errors.New("Test ES failure")
+}
+
+func (tc failClient) ClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error) {
 	return &elastic.ClusterHealthResponse{}, errors.New("Test ES failure")
 }
 
@@ -621,7 +960,9 @@ type mockClient struct {
 	queryResponse string
 }
 
-func (mc mockClient) query(indexName string, query elastic.Query, resultLimit int) (*elastic.SearchResult, error) {
+func (mc mockClient) SetElasticClient(client *elastic.Client) {}
+
+func (mc mockClient) Search(ctx context.Context, query service.SearchQuery) (*elastic.SearchResult, error) {
 	var searchResult elastic.SearchResult
 	err := json.Unmarshal([]byte(mc.queryResponse), &searchResult)
 	if err != nil {
@@ -630,7 +971,7 @@ func (mc mockClient) query(indexName string, query elastic.Query, resultLimit in
 	return &searchResult, nil
 }
 
-func (mc mockClient) multiSearchQuery(indexName string, searchRequests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
+func (mc mockClient) MultiSearch(ctx context.Context, index string, requests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
 	var searchResult elastic.MultiSearchResult
 	err := json.Unmarshal([]byte(mc.queryResponse), &searchResult)
 	if err != nil {
@@ -639,7 +980,16 @@ func (mc mockClient) multiSearchQuery(indexName string, searchRequests ...*elast
 	return &searchResult, nil
 }
 
-func (mc mockClient) getClusterHealth() (*elastic.ClusterHealthResponse, error) {
+func (mc mockClient) Scroll(ctx context.Context, query service.SearchQuery, handle func(*elastic.SearchResult) error) error {
+	searchResult, _ := mc.Search(ctx, query)
+	return handle(searchResult)
+}
+
+func (mc mockClient) MultiGet(ctx context.Context, index string, ids []string) (*elastic.MgetResponse, error) {
+	return &elastic.MgetResponse{}, nil
+}
+
+func (mc mockClient) ClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error) {
 	return &elastic.ClusterHealthResponse{}, nil
 }
 
@@ -653,6 +1003,11 @@ const (
 	requestURLWithScore              = "http://nothing/at/all?include_score=true"
 	requestURLWithScoreAndDeprecated = "http://nothing/at/all?include_score=true&include_deprecated=true"
 	requestURLWithAllAuthorities     = "http://nothing/at/all?searchAllAuthorities=true"
+	requestURLWithFacets             = "http://nothing/at/all?include_facets=true"
+	requestURLWithHighlight          = "http://nothing/at/all?highlight=true"
+	requestURLWithExplain            = "http://nothing/at/all?explain=true"
+	requestURLWithFields             = "http://nothing/at/all?fields=id,prefLabel,score"
+	requestURLWithMetrics            = "http://nothing/at/all?include_metrics=true"
 )
 
 const validResponse = `{
@@ -688,7 +1043,9 @@ const validResponse = `{
             "Foobar SpA"
           ],
           "countryCode": "CA",
-          "countryOfIncorporation": "US"
+          "countryOfIncorporation": "US",
+          "lastModified": "2019-05-13T10:12:02.123Z",
+          "publishReference": "tid_foobar"
         }
       },
       {
@@ -710,6 +1067,131 @@ const validResponse = `{
             "Foobar GMBH"
           ]}}]}
 }`
+const validResponseWithFacets = `{
+  "took": 12,
+  "timed_out": false,
+  "hits": {
+    "total": 16,
+    "max_score": 9.992676,
+    "hits": [
+      {
+        "_index": "concept",
+        "_type": "organisations",
+        "_id": "9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+        "_score": 9.992676,
+        "_source": {
+          "id": "http://api.ft.com/things/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "apiUrl": "http://api.ft.com/organisations/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "prefLabel": "Foobar SpA",
+          "directType": "http://www.ft.com/ontology/company/PublicCompany"
+        }
+      }
+    ]
+  },
+  "aggregations": {
+    "type": {"buckets": [
+      {"key": "http://www.ft.com/ontology/company/PublicCompany", "doc_count": 12},
+      {"key": "http://www.ft.com/ontology/organisation/Organisation", "doc_count": 4}
+    ]},
+    "authorities": {"buckets": [{"key": "TME", "doc_count": 16}]},
+    "isDeprecated": {"buckets": [{"key": 0, "key_as_string": "false", "doc_count": 16}]}
+  }
+}`
+
+const validResponseWithHighlight = `{
+  "took": 12,
+  "timed_out": false,
+  "hits": {
+    "total": 1,
+    "max_score": 9.992676,
+    "hits": [
+      {
+        "_index": "concept",
+        "_type": "organisations",
+        "_id": "9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+        "_score": 9.992676,
+        "_source": {
+          "id": "http://api.ft.com/things/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "apiUrl": "http://api.ft.com/organisations/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "prefLabel": "Foobar SpA",
+          "directType": "http://www.ft.com/ontology/organisation/Organisation",
+          "aliases": ["Foobar SpA", "Foobar Holdings"]
+        },
+        "highlight": {
+          "aliases": ["<em>Foobar</em> Holdings"]
+        }
+      }
+    ]
+  }
+}`
+
+const validResponseWithMetrics = `{
+  "took": 12,
+  "timed_out": false,
+  "hits": {
+    "total": 1,
+    "max_score": 9.992676,
+    "hits": [
+      {
+        "_index": "concept",
+        "_type": "organisations",
+        "_id": "9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+        "_score": 9.992676,
+        "_source": {
+          "id": "http://api.ft.com/things/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "apiUrl": "http://api.ft.com/organisations/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "prefLabel": "Foobar SpA",
+          "directType": "http://www.ft.com/ontology/organisation/Organisation",
+          "metrics": {
+            "annotationsCount": 1250,
+            "prevWeekAnnotationsCount": 31
+          }
+        }
+      }
+    ]
+  }
+}`
+
+const validResponseWithExplanation = `{
+  "took": 12,
+  "timed_out": false,
+  "hits": {
+    "total": 1,
+    "max_score": 6.5,
+    "hits": [
+      {
+        "_index": "concept",
+        "_type": "organisations",
+        "_id": "9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+        "_score": 6.5,
+        "_source": {
+          "id": "http://api.ft.com/things/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "apiUrl": "http://api.ft.com/organisations/9a0dd8b8-2ae4-34ca-8639-cfef69711eb9",
+          "prefLabel": "Foobar SpA",
+          "directType": "http://www.ft.com/ontology/organisation/Organisation",
+          "aliases": ["Foobar SpA", "Foobar Holdings"]
+        },
+        "matched_queries": ["prefLabelExactMatch", "textMatch"],
+        "_explanation": {
+          "value": 6.5,
+          "description": "product of:",
+          "details": [
+            {
+              "value": 13,
+              "description": "sum of:",
+              "details": [
+                {"value": 4, "description": "sum of:"},
+                {"value": 9, "description": "weight(prefLabel.raw:Foobar SpA in 0) [PerFieldSimilarity], result of:"}
+              ]
+            },
+            {"value": 0.5, "description": "coord(2/4)"}
+          ]
+        }
+      }
+    ]
+  }
+}`
+
 const validResponseDeprecated = `{
   "took": 111,
   "timed_out": false,
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	cacheHitsMetric   = "concept-search-cache.hits"
+	cacheMissesMetric = "concept-search-cache.misses"
+)
+
+// CachingConceptSearchService is a ConceptSearchService which caches the results of the typeahead searches and
+// of the listings by type, which are the most repeated queries. Lookups by id and exports are never cached.
+type CachingConceptSearchService interface {
+	ConceptSearchService
+	Purge()
+}
+
+type cachingConceptSearchService struct {
+	ConceptSearchService
+	cache  *lruCache
+	hits   metrics.Counter
+	misses metrics.Counter
+}
+
+// NewCachingConceptSearchService wraps the given service with a cache holding the results of up to size queries,
+// each for the given TTL (or until evicted, if the TTL is not positive). Hits and misses are reported to the registry.
+func NewCachingConceptSearchService(delegate ConceptSearchService, size int, ttl time.Duration, registry metrics.Registry) CachingConceptSearchService {
+	return &cachingConceptSearchService{
+		ConceptSearchService: delegate,
+		cache:                newLRUCache(size, ttl),
+		hits:                 metrics.GetOrRegisterCounter(cacheHitsMetric, registry),
+		misses:               metrics.GetOrRegisterCounter(cacheMissesMetric, registry),
+	}
+}
+
+func (s *cachingConceptSearchService) FindAllConceptsByType(ctx context.Context, conceptType string, opts ListOptions) (SearchResult, error) {
+	return s.cached(cacheKey{Method: "FindAllConceptsByType", Types: []string{conceptType}, Options: opts}, func() (SearchResult, error) {
+		return s.ConceptSearchService.FindAllConceptsByType(ctx, conceptType, opts)
+	})
+}
+
+func (s *cachingConceptSearchService) SearchConceptByTextAndTypes(ctx context.Context, textQuery string, conceptTypes []string, opts SearchOptions) (SearchResult, error) {
+	return s.cached(cacheKey{Method: "SearchConceptByTextAndTypes", Text: textQuery, Types: conceptTypes, Options: opts}, func() (SearchResult, error) {
+		return s.ConceptSearchService.SearchConceptByTextAndTypes(ctx, textQuery, conceptTypes, opts)
+	})
+}
+
+func (s *cachingConceptSearchService) SearchConceptByTextAndTypesWithBoost(ctx context.Context, textQuery string, conceptTypes []string, boostType string, opts SearchOptions) (SearchResult, error) {
+	return s.cached(cacheKey{Method: "SearchConceptByTextAndTypesWithBoost", Text: textQuery, Types: conceptTypes, Boost: boostType, Options: opts}, func() (SearchResult, error) {
+		return s.ConceptSearchService.SearchConceptByTextAndTypesWithBoost(ctx, textQuery, conceptTypes, boostType, opts)
+	})
+}
+
+func (s *cachingConceptSearchService) Purge() {
+	s.cache.purge()
+}
+
+// cacheKey holds every parameter of a cached query; the options are all part of it, so that any of them
+// (authorities, deprecated flag, paging, facets...) leads to a different entry.
+type cacheKey struct {
+	Method  string      `json:"method"`
+	Text    string      `json:"text,omitempty"`
+	Types   []string    `json:"types"`
+	Boost   string      `json:"boost,omitempty"`
+	Options interface{} `json:"options"`
+}
+
+func (s *cachingConceptSearchService) cached(key cacheKey, find func() (SearchResult, error)) (SearchResult, error) {
+	k, err := json.Marshal(key)
+	if err != nil {
+		log.WithError(err).Warn("could not compute the cache key of the query, the cache is bypassed")
+		return find()
+	}
+
+	if result, found := s.cache.get(string(k)); found {
+		s.hits.Inc(1)
+		return result, nil
+	}
+	s.misses.Inc(1)
+
+	result, err := find()
+	if err != nil {
+		return result, err // errors are not cached, the next query should be tried again
+	}
+	s.cache.add(string(k), result)
+	return result, nil
+}
+
+type lruEntry struct {
+	key     string
+	result  SearchResult
+	expires time.Time
+}
+
+// lruCache is a size bounded cache of search results, evicting the least recently used ones first
+type lruCache struct {
+	lock    *sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // the most recently used entries first
+	now     func() time.Time
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		lock:    &sync.Mutex{},
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *lruCache) get(key string) (SearchResult, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, found := c.entries[key]
+	if !found {
+		return SearchResult{}, false
+	}
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(element)
+		return SearchResult{}, false
+	}
+	c.order.MoveToFront(element)
+	return entry.result, true
+}
+
+func (c *lruCache) add(key string, result SearchResult) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry := &lruEntry{key: key, result: result, expires: c.now().Add(c.ttl)}
+	if element, found := c.entries[key]; found {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
+
+func (c *lruCache) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
+
+func (c *lruCache) purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
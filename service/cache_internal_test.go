@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := newLRUCache(2, time.Minute)
+
+	cache.add("a", SearchResult{Total: 1})
+	cache.add("b", SearchResult{Total: 2})
+	_, found := cache.get("a") // a is now the most recently used
+	assert.True(t, found)
+	cache.add("c", SearchResult{Total: 3})
+
+	assert.Equal(t, 2, cache.len())
+	_, found = cache.get("b")
+	assert.False(t, found, "the least recently used entry should have been evicted")
+	result, found := cache.get("a")
+	assert.True(t, found)
+	assert.Equal(t, int64(1), result.Total)
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	now := time.Now()
+	cache := newLRUCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.add("a", SearchResult{Total: 1})
+	_, found := cache.get("a")
+	assert.True(t, found)
+
+	now = now.Add(time.Minute)
+	_, found = cache.get("a")
+	assert.False(t, found, "the entry should have expired")
+	assert.Equal(t, 0, cache.len())
+}
+
+func TestDisabledLRUCache(t *testing.T) {
+	cache := newLRUCache(0, time.Minute)
+	cache.add("a", SearchResult{Total: 1})
+	_, found := cache.get("a")
+	assert.False(t, found)
+}
@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCachingService(size int, ttl time.Duration) (service.CachingConceptSearchService, *servicetest.InMemoryConceptRepository, metrics.Registry) {
+	repository := newTestInMemoryRepository()
+	registry := metrics.NewRegistry()
+	return service.NewCachingConceptSearchService(service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10), size, ttl, registry), repository, registry
+}
+
+func cacheCount(registry metrics.Registry, name string) int64 {
+	return registry.Get(name).(metrics.Counter).Count()
+}
+
+func TestCachedListing(t *testing.T) {
+	svc, repository, registry := newTestCachingService(10, time.Minute)
+
+	first, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+	second, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Len(t, repository.Queries(), 1, "the second listing should come from the cache")
+	assert.Equal(t, int64(1), cacheCount(registry, service.CacheHitsMetric))
+	assert.Equal(t, int64(1), cacheCount(registry, service.CacheMissesMetric))
+
+	_, err = svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{Authorities: []string{"TME"}})
+	require.NoError(t, err)
+	_, err = svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{IncludeDeprecated: true})
+	require.NoError(t, err)
+	assert.Len(t, repository.Queries(), 3, "every option should be part of the cache key")
+	assert.Equal(t, int64(3), cacheCount(registry, service.CacheMissesMetric))
+}
+
+func TestCachedSearch(t *testing.T) {
+	svc, repository, registry := newTestCachingService(10, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.SearchConceptByTextAndTypes(context.Background(), "lu", []string{ftBrandType}, service.SearchOptions{})
+		require.NoError(t, err)
+	}
+	_, err := svc.SearchConceptByTextAndTypesWithBoost(context.Background(), "lu", []string{ftPeopleType}, "authors", service.SearchOptions{})
+	require.NoError(t, err)
+	_, err = svc.SearchConceptByTextAndTypes(context.Background(), "lu", []string{ftPeopleType}, service.SearchOptions{})
+	require.NoError(t, err)
+
+	assert.Len(t, repository.Queries(), 3)
+	assert.Equal(t, int64(2), cacheCount(registry, service.CacheHitsMetric))
+	assert.Equal(t, int64(3), cacheCount(registry, service.CacheMissesMetric))
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	svc, repository, _ := newTestCachingService(10, time.Minute)
+
+	repository.FailWith(errors.New("computer says no"))
+	_, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	assert.Error(t, err)
+
+	repository.FailWith(nil)
+	result, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Concepts, 2)
+}
+
+func TestLookupsAreNotCached(t *testing.T) {
+	svc, repository, _ := newTestCachingService(10, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.FindConceptByUUID(context.Background(), "40f636a3-5507-4311-9629-95376007cb7b", false)
+		require.NoError(t, err)
+	}
+	assert.Len(t, repository.Queries(), 2)
+}
+
+func TestPurgeCache(t *testing.T) {
+	svc, repository, _ := newTestCachingService(10, time.Minute)
+
+	_, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+	svc.Purge()
+	_, err = svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+
+	assert.Len(t, repository.Queries(), 2, "the purged results should be queried again")
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/Financial-Times/concept-search-api/util"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// ScoreExplanation tells how the score of a concept has been computed: the contribution of each named clause of the
+// query it matched, summarised from the explanation of Elasticsearch, which is given in full as details.
+// The contributions are left out whenever the explanation cannot be matched with the clauses of the query.
+type ScoreExplanation struct {
+	Score         float64                    `json:"score"`
+	Contributions map[string]float64         `json:"contributions,omitempty"`
+	Details       *elastic.SearchExplanation `json:"details,omitempty"`
+}
+
+// ExplainHit summarises the explanation of a hit found by a bool query whose scoring clauses have been named.
+// The names are the ones of the must clauses followed by the should ones, in the order they were added to the query,
+// which is the order Elasticsearch explains the clauses in.
+func ExplainHit(hit *elastic.SearchHit, clauses []string) *ScoreExplanation {
+	if hit.Explanation == nil {
+		return nil
+	}
+	explanation := &ScoreExplanation{Score: hit.Explanation.Value, Details: hit.Explanation}
+
+	matched := map[string]bool{}
+	for _, name := range hit.MatchedQueries {
+		matched[name] = true
+	}
+	names := []string{}
+	for _, name := range clauses {
+		if matched[name] {
+			names = append(names, name)
+		}
+	}
+
+	scores := []float64{}
+	for _, detail := range clausesExplanation(*hit.Explanation).Details {
+		// the filters are explained too, but they do not score
+		if !strings.HasPrefix(detail.Description, "match on required clause") {
+			scores = append(scores, detail.Value)
+		}
+	}
+	if len(scores) != len(names) {
+		return explanation
+	}
+
+	explanation.Contributions = map[string]float64{}
+	for i, name := range names {
+		explanation.Contributions[name] = scores[i]
+	}
+	return explanation
+}
+
+// clausesExplanation returns the explanation of the sum of the clauses of a bool query, which is scaled by
+// a coordination factor when only some of the clauses matched
+func clausesExplanation(e elastic.SearchExplanation) elastic.SearchExplanation {
+	if strings.HasPrefix(e.Description, "product of") && len(e.Details) > 0 && strings.HasPrefix(e.Details[0].Description, "sum of") {
+		return e.Details[0]
+	}
+	return e
+}
+
+// addExplanations explains the score of the concepts found by a query, using the hits of its result
+func addExplanations(concepts Concepts, result *elastic.SearchResult, clauses []string) {
+	explanations := map[string]*ScoreExplanation{}
+	for _, hit := range result.Hits.Hits {
+		explanations[hit.Id] = ExplainHit(hit, clauses)
+	}
+	for i, c := range concepts {
+		if uuid, ok := util.ConceptUUID(c.Id); ok {
+			concepts[i].Explanation = explanations[uuid]
+		}
+	}
+}
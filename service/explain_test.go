@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func TestExplainHit(t *testing.T) {
+	clauses := []string{"textMatch", "exactMatch", "typeBoost.topics", "popularity"}
+	filter := elastic.SearchExplanation{Value: 0, Description: "match on required clause, product of:"}
+
+	testCases := []struct {
+		name     string
+		hit      *elastic.SearchHit
+		expected *service.ScoreExplanation
+	}{
+		{
+			name:     "no explanation",
+			hit:      &elastic.SearchHit{MatchedQueries: []string{"textMatch"}},
+			expected: nil,
+		},
+		{
+			name: "sum of the clauses",
+			hit: &elastic.SearchHit{
+				MatchedQueries: []string{"popularity", "textMatch", "exactMatch"},
+				Explanation: &elastic.SearchExplanation{Value: 22, Description: "sum of:", Details: []elastic.SearchExplanation{
+					{Value: 2, Description: "sum of:"}, filter, {Value: 15, Description: "weight(prefLabel.exact_match:donald trump)"}, {Value: 5, Description: "function score"},
+				}},
+			},
+			expected: &service.ScoreExplanation{Score: 22, Contributions: map[string]float64{"textMatch": 2, "exactMatch": 15, "popularity": 5}},
+		},
+		{
+			name: "coordinated sum of the clauses",
+			hit: &elastic.SearchHit{
+				MatchedQueries: []string{"textMatch", "typeBoost.topics"},
+				Explanation: &elastic.SearchExplanation{Value: 1.75, Description: "product of:", Details: []elastic.SearchExplanation{
+					{Value: 3.5, Description: "sum of:", Details: []elastic.SearchExplanation{{Value: 2, Description: "sum of:"}, {Value: 1.5, Description: "_type:topics"}}},
+					{Value: 0.5, Description: "coord(2/4)"},
+				}},
+			},
+			expected: &service.ScoreExplanation{Score: 1.75, Contributions: map[string]float64{"textMatch": 2, "typeBoost.topics": 1.5}},
+		},
+		{
+			name: "unexpected explanation",
+			hit: &elastic.SearchHit{
+				MatchedQueries: []string{"textMatch", "exactMatch"},
+				Explanation:    &elastic.SearchExplanation{Value: 2, Description: "weight(prefLabel.edge_ngram:don)"},
+			},
+			expected: &service.ScoreExplanation{Score: 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		actual := service.ExplainHit(tc.hit, clauses)
+		if tc.expected == nil {
+			assert.Nil(t, actual, tc.name)
+			continue
+		}
+		require.NotNil(t, actual, tc.name)
+		assert.Equal(t, tc.expected.Score, actual.Score, tc.name)
+		assert.Equal(t, tc.expected.Contributions, actual.Contributions, tc.name)
+		assert.Equal(t, tc.hit.Explanation, actual.Details, "the explanation of Elasticsearch should be given in full")
+	}
+}
+
+func TestSearchWithExplain(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	_, err := svc.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftPeopleType}, "authors", service.SearchOptions{Explain: true})
+	require.NoError(t, err)
+	_, err = svc.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftGenreType}, service.SearchOptions{})
+	require.NoError(t, err)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 2)
+	explained := queryJSON(t, queries[0])
+	assert.Contains(t, explained, `"explain":true`)
+	for _, name := range []string{"textMatch", "termMatch", "exactMatch", "aliasesExactMatch", "typeBoost.people", "scopeNote", "phraseMatch", "popularity", "lastWeekPopularity", "authors"} {
+		assert.Contains(t, explained, `"_name":"`+name+`"`, "every scoring clause should be named")
+	}
+	assert.NotContains(t, queryJSON(t, queries[1]), `"explain"`, "the scores should only be explained when asked to")
+}
+
+func TestAddExplanations(t *testing.T) {
+	concepts := service.Concepts{
+		{Id: "http://www.ft.com/thing/2a88a647-59bc-4043-8f1b-5add71ddb3a0"},
+		{Id: "http://www.ft.com/thing/61d707b5-6fab-3541-b017-49b72de80772"},
+	}
+	result := &elastic.SearchResult{Hits: &elastic.SearchHits{Hits: []*elastic.SearchHit{
+		{Id: "61d707b5-6fab-3541-b017-49b72de80772", MatchedQueries: []string{"textMatch"}, Explanation: &elastic.SearchExplanation{Value: 1, Description: "sum of:", Details: []elastic.SearchExplanation{{Value: 1}}}},
+		{Id: "2a88a647-59bc-4043-8f1b-5add71ddb3a0", MatchedQueries: []string{"textMatch"}, Explanation: &elastic.SearchExplanation{Value: 2, Description: "sum of:", Details: []elastic.SearchExplanation{{Value: 2}}}},
+	}}}
+
+	service.AddExplanations(concepts, result, []string{"textMatch"})
+	assert.Equal(t, map[string]float64{"textMatch": 2}, concepts[0].Explanation.Contributions)
+	assert.Equal(t, map[string]float64{"textMatch": 1}, concepts[1].Explanation.Contributions)
+}
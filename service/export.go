@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Financial-Times/concept-search-api/util"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const exportBatchSize = 500
+
+// ExportConceptsByType scrolls through every concept of the given type, handing them over one at a time.
+// Paging and sorting options are ignored, as all the matching concepts are exported; the export stops at the first error
+// returned by the handle function.
+func (s *esConceptSearchService) ExportConceptsByType(ctx context.Context, conceptType string, opts ListOptions, handle func(EsConceptModel) error) error {
+	filter, err := util.NewConceptTypeFilter(conceptType)
+	if err != nil {
+		return err
+	}
+
+	query := SearchQuery{Index: s.getIndexForAuthoritiesParam(opts.SearchAllAuthorities), Types: []string{filter.EsType}}
+	query.Source = elastic.NewSearchSource().
+		Query(addListFilters(addSubtypeFilter(elastic.NewBoolQuery(), filter), opts)).
+		Size(exportBatchSize).
+		Sort("_doc", true) // the cheapest order to scroll in
+
+	err = s.repository.Scroll(ctx, query, func(result *elastic.SearchResult) error {
+		for _, hit := range result.Hits.Hits {
+			esConcept := EsConceptModel{}
+			if err := json.Unmarshal(*hit.Source, &esConcept); err != nil {
+				log.Warnf("unmarshallable response from ElasticSearch: %v", err)
+				continue
+			}
+			esConcept.Id = correctPath(esConcept.Id)
+			if err := handle(esConcept); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("error: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package service
+
+// The tests running the services against servicetest.InMemoryConceptRepository live in the service_test package, as
+// servicetest depends on this package. The internals they check are exported to them here.
+
+const (
+	CacheHitsMetric   = cacheHitsMetric
+	CacheMissesMetric = cacheMissesMetric
+)
+
+var (
+	ErrEmptyIdsParameter           = errEmptyIdsParameter
+	ErrTooManyLookupIds            = errTooManyLookupIds
+	ErrInvalidSearchAfterParameter = errInvalidSearchAfterParameter
+	ErrInvalidSortParameter        = errInvalidSortParameter
+	ErrEmptyTermsParameter         = errEmptyTermsParameter
+	ErrFacetsWithBestMatchTerms    = errFacetsWithBestMatchTerms
+	ErrFuzzinessWithBestMatchTerms = errFuzzinessWithBestMatchTerms
+	ErrInvalidFilterTypeParameter  = errInvalidFilterTypeParameter
+
+	TermSearchClauses             = termSearchClauses
+	BoostedBestMatchSearchClauses = boostedBestMatchSearchClauses
+
+	AddExplanations        = addExplanations
+	SearchResultToConcepts = searchResultToConcepts
+	BestMatchSearchSource  = bestMatchSearchSource
+	EncodeCursor           = encodeCursor
+	DecodeCursor           = decodeCursor
+	NextCursor             = nextCursor
+)
+
+func (p *RankingProfiles) ReloadIfModified() {
+	p.reloadIfModified()
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const maxFacetBuckets = 50
+
+// the facets returned to the clients, along with the fields they are aggregated on
+var facetFields = []struct {
+	name  string
+	field string
+}{
+	{"type", "directType"},
+	{"authorities", "authorities"},
+	{"isDeprecated", "isDeprecated"},
+	{"isFTAuthor", "isFTAuthor"},
+}
+
+// FacetBucket holds the number of concepts matching a query which have the given value in a faceted field.
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facets holds the buckets of every facet, keyed by facet name.
+type Facets map[string][]FacetBucket
+
+// FacetAggregations returns the terms aggregations to be added to a query in order to compute its facets.
+func FacetAggregations() map[string]elastic.Aggregation {
+	aggs := make(map[string]elastic.Aggregation)
+	for _, f := range facetFields {
+		aggs[f.name] = elastic.NewTermsAggregation().Field(f.field).Size(maxFacetBuckets)
+	}
+	return aggs
+}
+
+func addFacetAggregations(source *elastic.SearchSource) *elastic.SearchSource {
+	for name, agg := range FacetAggregations() {
+		source = source.Aggregation(name, agg)
+	}
+	return source
+}
+
+// FacetsFromResult reads the facets out of the aggregations of an ES search result.
+func FacetsFromResult(result *elastic.SearchResult) Facets {
+	facets := Facets{}
+	for _, f := range facetFields {
+		buckets := []FacetBucket{}
+		if terms, found := result.Aggregations.Terms(f.name); found {
+			for _, b := range terms.Buckets {
+				buckets = append(buckets, FacetBucket{Value: bucketValue(b), Count: b.DocCount})
+			}
+		}
+		facets[f.name] = buckets
+	}
+	return facets
+}
+
+func bucketValue(b *elastic.AggregationBucketKeyItem) string {
+	// boolean fields are aggregated on 0/1 keys, their string representation is the one we want
+	if b.KeyAsString != nil {
+		return *b.KeyAsString
+	}
+	return fmt.Sprint(b.Key)
+}
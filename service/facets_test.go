@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const aggregationsResponse = `{
+	"hits": {"total": 16, "hits": []},
+	"aggregations": {
+		"type": {"buckets": [
+			{"key": "http://www.ft.com/ontology/person/Person", "doc_count": 12},
+			{"key": "http://www.ft.com/ontology/organisation/Organisation", "doc_count": 4}
+		]},
+		"authorities": {"buckets": [
+			{"key": "TME", "doc_count": 10},
+			{"key": "FACTSET", "doc_count": 3}
+		]},
+		"isDeprecated": {"buckets": [
+			{"key": 0, "key_as_string": "false", "doc_count": 16}
+		]}
+	}
+}`
+
+func TestFacetAggregations(t *testing.T) {
+	aggs := FacetAggregations()
+	assert.Len(t, aggs, len(facetFields))
+
+	src, err := aggs["type"].Source()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"terms": map[string]interface{}{"field": "directType", "size": maxFacetBuckets}}, src)
+}
+
+func TestFacetsFromResult(t *testing.T) {
+	var result elastic.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(aggregationsResponse), &result))
+
+	facets := FacetsFromResult(&result)
+
+	assert.Equal(t, []FacetBucket{
+		{Value: "http://www.ft.com/ontology/person/Person", Count: 12},
+		{Value: "http://www.ft.com/ontology/organisation/Organisation", Count: 4},
+	}, facets["type"])
+	assert.Equal(t, []FacetBucket{{Value: "TME", Count: 10}, {Value: "FACTSET", Count: 3}}, facets["authorities"])
+	assert.Equal(t, []FacetBucket{{Value: "false", Count: 16}}, facets["isDeprecated"])
+	assert.Equal(t, []FacetBucket{}, facets["isFTAuthor"], "a missing aggregation should have no buckets")
+}
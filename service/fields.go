@@ -0,0 +1,60 @@
+package service
+
+import (
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// conceptSourceFields maps the fields of the concepts, as named in the responses, to the fields of their Elasticsearch
+// documents. The score has none, as it is a property of the hits.
+var conceptSourceFields = map[string]string{
+	"id":                     "id",
+	"apiUrl":                 "apiUrl",
+	"prefLabel":              "prefLabel",
+	"type":                   "directType",
+	"types":                  "types",
+	"directType":             "directType",
+	"aliases":                "aliases",
+	"scopeNote":              "scopeNote",
+	"countryCode":            "countryCode",
+	"countryOfIncorporation": "countryOfIncorporation",
+	"isFTAuthor":             "isFTAuthor",
+	"isDeprecated":           "isDeprecated",
+	"lastModified":           "lastModified",
+	"publishReference":       "publishReference",
+	"metrics":                "metrics",
+}
+
+// fetchFields restricts the fields of the documents fetched by a search to the ones needed for the given fields of the
+// concepts, or fetches them whole when no fields are given. The id is always fetched, as the concepts are told apart by it.
+func fetchFields(source *elastic.SearchSource, fields []string) *elastic.SearchSource {
+	if len(fields) == 0 {
+		return source
+	}
+
+	includes := []string{"id"}
+	for _, field := range fields {
+		if sourceField, found := conceptSourceFields[field]; found && !hasField(includes, sourceField) {
+			includes = append(includes, sourceField)
+		}
+	}
+	return source.FetchSourceContext(elastic.NewFetchSourceContext(true).Include(includes...))
+}
+
+// returnedFields returns the picked fields of the concepts, along with the metrics when they are included. Unlike
+// picking them, including the metrics does not restrict the fields fetched from Elasticsearch.
+func returnedFields(fields []string, includeMetrics bool) []string {
+	if includeMetrics && !hasField(fields, "metrics") {
+		return append(append([]string{}, fields...), "metrics")
+	}
+	return fields
+}
+
+// hasField tells whether the given fields include the given one
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
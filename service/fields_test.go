@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func TestListingWithFieldsFetchesOnlyTheirSource(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	result, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{Fields: []string{"prefLabel", "type", "directType", "score"}})
+	require.NoError(t, err)
+	assert.Len(t, result.Concepts, 2)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 1)
+	assert.Contains(t, queryJSON(t, queries[0]), `"_source":{"includes":["id","prefLabel","directType"]}`, "the id should always be fetched, and the score has no source")
+}
+
+func TestListingWithoutFieldsFetchesTheWholeSource(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	_, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 1)
+	assert.NotContains(t, queryJSON(t, queries[0]), `"_source"`)
+}
+
+func TestSearchResultToConceptsWithFields(t *testing.T) {
+	source, err := json.Marshal(service.EsConceptModel{
+		Id:         "http://api.ft.com/things/2a88a647-59bc-4043-8f1b-5add71ddb3a0",
+		PrefLabel:  "Analysis",
+		DirectType: ftGenreType,
+		Aliases:    []string{"Analyses"},
+		Metrics:    &service.ConceptMetrics{AnnotationsCount: 12, PrevWeekAnnotationsCount: 3},
+	})
+	require.NoError(t, err)
+	raw := json.RawMessage(source)
+	score := 1.5
+	result := &elastic.SearchResult{Hits: &elastic.SearchHits{Hits: []*elastic.SearchHit{{Source: &raw, Score: &score}}}}
+
+	concepts := service.SearchResultToConcepts(result, nil)
+	require.Len(t, concepts, 1)
+	assert.Nil(t, concepts[0].Aliases, "the aliases should only be there when asked for")
+	assert.Nil(t, concepts[0].Metrics, "the metrics should only be there when asked for")
+	assert.Zero(t, concepts[0].Score, "the score should only be there when asked for")
+
+	concepts = service.SearchResultToConcepts(result, []string{"aliases", "metrics", "score"})
+	require.Len(t, concepts, 1)
+	assert.Equal(t, []string{"Analyses"}, concepts[0].Aliases)
+	assert.Equal(t, &service.ConceptMetrics{AnnotationsCount: 12, PrevWeekAnnotationsCount: 3}, concepts[0].Metrics)
+	assert.Equal(t, 1.5, concepts[0].Score)
+}
+
+func TestListingWithMetricsFetchesTheWholeSource(t *testing.T) {
+	repository := servicetest.NewInMemoryConceptRepository().
+		Add(testDefaultIndex, esGenreType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", PrefLabel: "Comment", DirectType: ftGenreType, Metrics: &service.ConceptMetrics{AnnotationsCount: 5, PrevWeekAnnotationsCount: 2}},
+		)
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	result, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{IncludeMetrics: true})
+	require.NoError(t, err)
+	require.Len(t, result.Concepts, 1)
+	assert.Equal(t, "Comment", result.Concepts[0].PrefLabel)
+	assert.Equal(t, &service.ConceptMetrics{AnnotationsCount: 5, PrevWeekAnnotationsCount: 2}, result.Concepts[0].Metrics)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 1)
+	assert.NotContains(t, queryJSON(t, queries[0]), `"_source"`, "including the metrics should not restrict the fields fetched")
+}
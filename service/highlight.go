@@ -0,0 +1,46 @@
+package service
+
+import (
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// the highlighted fields returned to the clients, along with the subfields they are highlighted on, by preference
+var highlightFields = []struct {
+	name      string
+	subfields []string
+}{
+	{"prefLabel", []string{"prefLabel.edge_ngram", "prefLabel"}},
+	{"aliases", []string{"aliases.edge_ngram", "aliases"}},
+}
+
+// Highlight holds the values of the fields a concept has been matched on, with the matching terms marked.
+type Highlight map[string][]string
+
+// NewHighlight returns the ES highlighting to be added to a query in order to tell why its results matched.
+func NewHighlight() *elastic.Highlight {
+	highlight := elastic.NewHighlight().PreTags("<em>").PostTags("</em>").RequireFieldMatch(true)
+	for _, f := range highlightFields {
+		for _, subfield := range f.subfields {
+			// no fragments, so that the whole value is returned
+			highlight = highlight.Fields(elastic.NewHighlighterField(subfield).NumOfFragments(0))
+		}
+	}
+	return highlight
+}
+
+// HighlightFromHit reads the highlighted fields out of an ES search hit, if any.
+func HighlightFromHit(hit *elastic.SearchHit) Highlight {
+	if len(hit.Highlight) == 0 {
+		return nil
+	}
+	highlight := Highlight{}
+	for _, f := range highlightFields {
+		for _, subfield := range f.subfields {
+			if values := hit.Highlight[subfield]; len(values) > 0 {
+				highlight[f.name] = values
+				break
+			}
+		}
+	}
+	return highlight
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func TestNewHighlight(t *testing.T) {
+	src, err := NewHighlight().Source()
+	require.NoError(t, err)
+
+	highlight := src.(map[string]interface{})
+	assert.Equal(t, []string{"<em>"}, highlight["pre_tags"])
+	assert.Equal(t, []string{"</em>"}, highlight["post_tags"])
+	assert.Equal(t, true, highlight["require_field_match"])
+	assert.Len(t, highlight["fields"], 4)
+}
+
+func TestHighlightFromHit(t *testing.T) {
+	hit := &elastic.SearchHit{
+		Highlight: elastic.SearchHitHighlight{
+			"prefLabel.edge_ngram": {"<em>Donald</em> J Trump"},
+			"prefLabel":            {"<em>Donald</em> J <em>Trump</em>"},
+			"aliases":              {"The <em>Donald</em>"},
+		},
+	}
+
+	highlight := HighlightFromHit(hit)
+
+	assert.Equal(t, Highlight{
+		"prefLabel": {"<em>Donald</em> J Trump"},
+		"aliases":   {"The <em>Donald</em>"},
+	}, highlight, "the edge_ngram highlights should be preferred, falling back to the folded ones")
+}
+
+func TestHighlightFromHitWithoutHighlight(t *testing.T) {
+	assert.Nil(t, HighlightFromHit(&elastic.SearchHit{}))
+}
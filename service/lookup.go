@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Financial-Times/concept-search-api/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MaxLookupIds is the maximum number of ids which can be looked up at once
+const MaxLookupIds = 5000
+
+var errTooManyLookupIds = util.NewParameterErrorf(util.ErrCodeInvalidBody, "", "too many ids, at most %v concepts can be looked up at once", MaxLookupIds)
+
+// LookupConcepts fetches the concepts with the given ids, which are either UUIDs or FT concept URIs.
+// The ids are looked up in the default index first, then the missing ones in the extended index; every id is
+// part of the result, apart from empty ones, the ids which were found in neither index being mapped to nil.
+func (s *esConceptSearchService) LookupConcepts(ctx context.Context, ids []string) (LookupResult, error) {
+	if len(ids) == 0 || containsOnlyEmptyValues(ids) {
+		return LookupResult{}, errEmptyIdsParameter
+	}
+	if len(ids) > MaxLookupIds {
+		return LookupResult{}, errTooManyLookupIds
+	}
+
+	esIds, err := util.ConceptUUIDs(ids)
+	if err != nil {
+		return LookupResult{}, err
+	}
+
+	found, err := s.multiGet(ctx, s.defaultIndex, missingIds(esIds, nil))
+	if err != nil {
+		return LookupResult{}, err
+	}
+
+	extended := make(map[string]*Concept)
+	if s.extendedSearchIndex != "" && s.extendedSearchIndex != s.defaultIndex {
+		if missing := missingIds(esIds, found); len(missing) > 0 {
+			if extended, err = s.multiGet(ctx, s.extendedSearchIndex, missing); err != nil {
+				return LookupResult{}, err
+			}
+		}
+	}
+
+	result := LookupResult{Concepts: make(map[string]*Concept), FromExtendedIndex: []string{}}
+	for i, id := range ids {
+		if _, done := result.Concepts[id]; done || id == "" {
+			continue
+		}
+		if concept, ok := found[esIds[i]]; ok {
+			result.Concepts[id] = concept
+		} else if concept, ok := extended[esIds[i]]; ok {
+			result.Concepts[id] = concept
+			result.FromExtendedIndex = append(result.FromExtendedIndex, id)
+		} else {
+			result.Concepts[id] = nil
+		}
+	}
+	return result, nil
+}
+
+// multiGet returns the concepts found in the index, by ES id
+func (s *esConceptSearchService) multiGet(ctx context.Context, index string, ids []string) (map[string]*Concept, error) {
+	result, err := s.repository.MultiGet(ctx, index, ids)
+	if err != nil {
+		log.Errorf("error: %v", err)
+		return nil, err
+	}
+
+	concepts := make(map[string]*Concept)
+	for _, doc := range result.Docs {
+		if !doc.Found || doc.Source == nil {
+			continue
+		}
+		concept, err := transformToConcept(doc.Source)
+		if err != nil {
+			log.Warnf("unmarshallable response from ElasticSearch: %v", err)
+			continue
+		}
+		concepts[doc.Id] = &concept
+	}
+	return concepts, nil
+}
+
+// missingIds returns the distinct ES ids which have not been found yet, in the order they were requested
+func missingIds(esIds []string, found map[string]*Concept) []string {
+	missing := []string{}
+	seen := make(map[string]bool)
+	for _, esId := range esIds {
+		if _, ok := found[esId]; ok || seen[esId] || esId == "" {
+			continue
+		}
+		seen[esId] = true
+		missing = append(missing, esId)
+	}
+	return missing
+}
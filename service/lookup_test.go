@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLookupRepository() *servicetest.InMemoryConceptRepository {
+	return newTestInMemoryRepository().
+		Add(testExtendedIndex, esOrganisationType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400", PrefLabel: "Apple Inc", DirectType: ftPublicCompanies},
+		)
+}
+
+func TestLookupConcepts(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestLookupRepository(), testDefaultIndex, testExtendedIndex, 1, 10)
+
+	ids := []string{
+		"2a88a647-59bc-4043-8f1b-5add71ddb3a0",
+		"http://api.ft.com/things/40f636a3-5507-4311-9629-95376007cb7b",
+		"eac853f5-3859-4c08-8540-55e043719400",
+		"0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3",
+	}
+	result, err := svc.LookupConcepts(context.Background(), ids)
+	require.NoError(t, err)
+
+	assert.Len(t, result.Concepts, 4, "every id should be part of the result, beyond the result limit")
+	require.NotNil(t, result.Concepts[ids[0]])
+	assert.Equal(t, "Analysis", result.Concepts[ids[0]].PrefLabel)
+	require.NotNil(t, result.Concepts[ids[1]])
+	assert.Equal(t, "http://www.ft.com/thing/40f636a3-5507-4311-9629-95376007cb7b", result.Concepts[ids[1]].Id)
+	require.NotNil(t, result.Concepts[ids[2]])
+	assert.Equal(t, "Apple Inc", result.Concepts[ids[2]].PrefLabel)
+	assert.Nil(t, result.Concepts[ids[3]], "unknown ids should be mapped to nil")
+	assert.Contains(t, result.Concepts, ids[3])
+
+	assert.Equal(t, []string{ids[2]}, result.FromExtendedIndex)
+}
+
+func TestLookupConceptsByURI(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestLookupRepository(), testDefaultIndex, testExtendedIndex, 10, 10)
+
+	ids := []string{
+		"http://www.ft.com/thing/2a88a647-59bc-4043-8f1b-5add71ddb3a0",
+		"http://api.ft.com/brands/40F636A3-5507-4311-9629-95376007CB7B",
+		"http://api.ft.com/organisations/eac853f5-3859-4c08-8540-55e043719400",
+	}
+	result, err := svc.LookupConcepts(context.Background(), ids)
+	require.NoError(t, err)
+
+	for _, id := range ids {
+		assert.NotNil(t, result.Concepts[id], "the concept should be found by its URI %v", id)
+	}
+	assert.Equal(t, []string{ids[2]}, result.FromExtendedIndex)
+}
+
+func TestLookupConceptsMalformedIds(t *testing.T) {
+	repository := newTestLookupRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, testExtendedIndex, 10, 10)
+
+	_, err := svc.LookupConcepts(context.Background(), []string{"2a88a647-59bc-4043-8f1b-5add71ddb3a0", "Analysis", "http://www.ft.com/thing/"})
+	assert.Equal(t, util.NewParameterError(util.ErrCodeInvalidParameter, "ids", "invalid concept ids 'Analysis', 'http://www.ft.com/thing/' (they should be UUIDs or FT concept URIs)"), err)
+}
+
+func TestFindConceptsByIdWithInMemoryRepository(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestLookupRepository(), testDefaultIndex, testExtendedIndex, 10, 10)
+
+	testCases := []struct {
+		ids         []string
+		expected    []string
+		expectedErr error
+	}{
+		{[]string{"2a88a647-59bc-4043-8f1b-5add71ddb3a0"}, []string{"Analysis"}, nil},
+		{[]string{"http://www.ft.com/thing/2a88a647-59bc-4043-8f1b-5add71ddb3a0", "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772"}, []string{"Analysis", "Comment"}, nil},
+		{[]string{"http://api.ft.com/brands/40f636a3-5507-4311-9629-95376007cb7b", ""}, []string{"Lex"}, nil},
+		{[]string{"0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3"}, []string{}, nil},
+		{[]string{"2a88a647-59bc-4043-8f1b-5add71ddb3a0", "xxx"}, nil, util.NewParameterError(util.ErrCodeInvalidParameter, "ids", "invalid concept ids 'xxx' (they should be UUIDs or FT concept URIs)")},
+		{[]string{""}, nil, service.ErrEmptyIdsParameter},
+	}
+
+	for _, tc := range testCases {
+		result, err := svc.FindConceptsById(context.Background(), tc.ids)
+		assert.Equal(t, tc.expectedErr, err, "ids=%v", tc.ids)
+		if tc.expectedErr != nil {
+			continue
+		}
+		prefLabels := []string{}
+		for _, c := range result.Concepts {
+			prefLabels = append(prefLabels, c.PrefLabel)
+		}
+		assert.Equal(t, tc.expected, prefLabels, "ids=%v", tc.ids)
+	}
+}
+
+func TestLookupConceptsWithoutExtendedIndex(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestLookupRepository(), testDefaultIndex, "", 10, 10)
+
+	result, err := svc.LookupConcepts(context.Background(), []string{"eac853f5-3859-4c08-8540-55e043719400"})
+	require.NoError(t, err)
+	assert.Nil(t, result.Concepts["eac853f5-3859-4c08-8540-55e043719400"])
+	assert.Empty(t, result.FromExtendedIndex)
+}
+
+func TestLookupConceptsInvalidIds(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestLookupRepository(), testDefaultIndex, testExtendedIndex, 10, 10)
+
+	_, err := svc.LookupConcepts(context.Background(), []string{})
+	assert.Equal(t, service.ErrEmptyIdsParameter, err)
+
+	_, err = svc.LookupConcepts(context.Background(), []string{"", ""})
+	assert.Equal(t, service.ErrEmptyIdsParameter, err)
+
+	result, err := svc.LookupConcepts(context.Background(), []string{"", "2a88a647-59bc-4043-8f1b-5add71ddb3a0"})
+	require.NoError(t, err)
+	assert.Len(t, result.Concepts, 1, "empty ids should not be part of the result")
+	assert.NotNil(t, result.Concepts["2a88a647-59bc-4043-8f1b-5add71ddb3a0"])
+
+	tooMany := make([]string, service.MaxLookupIds+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("%036d", i)
+	}
+	_, err = svc.LookupConcepts(context.Background(), tooMany)
+	assert.Equal(t, service.ErrTooManyLookupIds, err)
+}
+
+func TestLookupConceptsFailure(t *testing.T) {
+	expectedErr := errors.New("computer says no")
+	svc := service.NewConceptSearchService(newTestLookupRepository().FailWith(expectedErr), testDefaultIndex, testExtendedIndex, 10, 10)
+
+	_, err := svc.LookupConcepts(context.Background(), []string{"2a88a647-59bc-4043-8f1b-5add71ddb3a0"})
+	assert.Equal(t, expectedErr, err)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -14,12 +15,15 @@ type EsConceptModel struct {
 	Types                  []string        `json:"types"`
 	DirectType             string          `json:"directType"`
 	Aliases                []string        `json:"aliases,omitempty"`
+	Authorities            []string        `json:"authorities,omitempty"`
 	IsFTAuthor             *string         `json:"isFTAuthor,omitempty"`
 	IsDeprecated           bool            `json:"isDeprecated,omitempty"`
 	ScopeNote              string          `json:"scopeNote,omitempty"`
 	Metrics                *ConceptMetrics `json:"metrics,omitempty"`
 	CountryCode            string          `json:"countryCode,omitempty"`
 	CountryOfIncorporation string          `json:"countryOfIncorporation,omitempty"`
+	LastModified           string          `json:"lastModified,omitempty"`
+	PublishReference       string          `json:"publishReference,omitempty"`
 }
 
 type ConceptMetrics struct {
@@ -28,19 +32,77 @@ type ConceptMetrics struct {
 }
 
 type Concept struct {
-	Id                     string `json:"id"`
-	ApiUrl                 string `json:"apiUrl"`
-	PrefLabel              string `json:"prefLabel"`
-	ConceptType            string `json:"type"`
-	IsFTAuthor             *bool  `json:"isFTAuthor,omitempty"`
-	IsDeprecated           bool   `json:"isDeprecated,omitempty"`
-	ScopeNote              string `json:"scopeNote,omitempty"`
-	CountryCode            string `json:"countryCode,omitempty"`
-	CountryOfIncorporation string `json:"countryOfIncorporation,omitempty"`
+	Id                     string            `json:"id"`
+	ApiUrl                 string            `json:"apiUrl"`
+	PrefLabel              string            `json:"prefLabel"`
+	ConceptType            string            `json:"type"`
+	Aliases                []string          `json:"aliases,omitempty"`
+	IsFTAuthor             *bool             `json:"isFTAuthor,omitempty"`
+	IsDeprecated           bool              `json:"isDeprecated,omitempty"`
+	ScopeNote              string            `json:"scopeNote,omitempty"`
+	CountryCode            string            `json:"countryCode,omitempty"`
+	CountryOfIncorporation string            `json:"countryOfIncorporation,omitempty"`
+	LastModified           string            `json:"lastModified,omitempty"`
+	PublishReference       string            `json:"publishReference,omitempty"`
+	Metrics                *ConceptMetrics   `json:"metrics,omitempty"`
+	Score                  float64           `json:"score,omitempty"`
+	Highlight              Highlight         `json:"highlight,omitempty"`
+	Explanation            *ScoreExplanation `json:"explanation,omitempty"`
 }
 
 type Concepts []Concept
 
+// ListOptions holds the optional parameters for listing all the concepts of a given type.
+// The concepts are listed alphabetically, unless sorted by popularity (see SortAlphabetical and SortPopularity).
+// Their metrics are only returned when included, or when picked as one of the fields.
+type ListOptions struct {
+	SearchAllAuthorities bool
+	IncludeDeprecated    bool
+	Authorities          []string
+	ModifiedSince        time.Time
+	From                 int
+	Size                 int
+	SearchAfter          string
+	Sort                 string
+	Fields               []string
+	IncludeMetrics       bool
+}
+
+// SearchOptions holds the optional parameters for a free text search of concepts.
+// The metrics of the concepts are only returned when included, or when picked as one of the fields.
+type SearchOptions struct {
+	SearchAllAuthorities bool
+	IncludeDeprecated    bool
+	Authorities          []string
+	IncludeFacets        bool
+	Fuzziness            string
+	Highlight            bool
+	Profile              string
+	Explain              bool
+	Fields               []string
+	IncludeMetrics       bool
+}
+
+// SearchResult holds the concepts found by a query, along with the metadata of the query which produced them
+// (including the ranking profile of the searches) and the cursor to be used for requesting the next page (if any).
+type SearchResult struct {
+	Concepts    []Concept
+	SearchAfter string
+	Total       int64
+	Took        int64
+	Index       string
+	Limit       int
+	Profile     string
+	Facets      Facets
+}
+
+// LookupResult holds the concept found for each of the looked up ids (nil for the ids which were not found),
+// along with the ids which were only found in the extended index.
+type LookupResult struct {
+	Concepts          map[string]*Concept
+	FromExtendedIndex []string
+}
+
 var (
 	incorrectPath = "http://api.ft.com/things/"
 )
@@ -54,6 +116,8 @@ func ConvertToSimpleConcept(esConcept EsConceptModel) Concept {
 	c.ScopeNote = esConcept.ScopeNote
 	c.CountryCode = esConcept.CountryCode
 	c.CountryOfIncorporation = esConcept.CountryOfIncorporation
+	c.LastModified = esConcept.LastModified
+	c.PublishReference = esConcept.PublishReference
 	if esConcept.IsFTAuthor != nil {
 		ftAuthor, err := strconv.ParseBool(*esConcept.IsFTAuthor)
 		if err != nil {
@@ -46,6 +46,8 @@ func TestConvertToSimpleConcept(t *testing.T) {
 
 	countryCode := "CA"
 	countryOfIncorporation := "US"
+	lastModified := "2019-05-13T10:12:02.123Z"
+	publishReference := "tid_test"
 	esConcept := EsConceptModel{
 		Id:                     id,
 		ApiUrl:                 apiUrl,
@@ -56,6 +58,8 @@ func TestConvertToSimpleConcept(t *testing.T) {
 		IsDeprecated:           true,
 		CountryCode:            countryCode,
 		CountryOfIncorporation: countryOfIncorporation,
+		LastModified:           lastModified,
+		PublishReference:       publishReference,
 	}
 
 	actual := ConvertToSimpleConcept(esConcept)
@@ -67,6 +71,8 @@ func TestConvertToSimpleConcept(t *testing.T) {
 	assert.Equal(t, true, actual.IsDeprecated, "isDeprecated")
 	assert.Equal(t, countryCode, actual.CountryCode, "countryCode")
 	assert.Equal(t, countryOfIncorporation, actual.CountryOfIncorporation, "countryOfIncorporation")
+	assert.Equal(t, lastModified, actual.LastModified, "lastModified")
+	assert.Equal(t, publishReference, actual.PublishReference, "publishReference")
 }
 
 func TestConvertToSimpleConceptWithIdCorrect(t *testing.T) {
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/Financial-Times/concept-search-api/util"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+var (
+	errInvalidFromParameter        = util.NewParameterError(util.ErrCodeInvalidParameter, "from", "invalid from parameter, it should be a positive number")
+	errInvalidSizeParameter        = util.NewParameterError(util.ErrCodeInvalidParameter, "size", "invalid size parameter, it should be a positive number")
+	errInvalidSearchAfterParameter = util.NewParameterError(util.ErrCodeInvalidParameter, "search_after", "invalid search_after parameter")
+	errFromWithSearchAfter         = util.NewParameterError(util.ErrCodeUnsupportedParameter, "from", "invalid parameters, 'from' cannot be combined with 'search_after'")
+	errInvalidSortParameter        = util.NewParameterError(util.ErrCodeInvalidParameter, "sort", "invalid sort parameter, it should be one of alphabetical, popularity")
+)
+
+const (
+	// SortAlphabetical lists the concepts by prefLabel and id, the default order
+	SortAlphabetical = "alphabetical"
+	// SortPopularity lists the most annotated concepts first, then by prefLabel and id
+	SortPopularity = "popularity"
+)
+
+// paginate applies the requested order and page to a type listing search, returning the effective page size.
+// Results are always sorted by prefLabel and id last, so that the search_after cursor is stable across requests.
+func (s *esConceptSearchService) paginate(source *elastic.SearchSource, opts ListOptions) (*elastic.SearchSource, int, error) {
+	if opts.From < 0 {
+		return nil, 0, errInvalidFromParameter
+	}
+	if opts.Size < 0 {
+		return nil, 0, errInvalidSizeParameter
+	}
+	if opts.From > 0 && opts.SearchAfter != "" {
+		return nil, 0, errFromWithSearchAfter
+	}
+
+	size := opts.Size
+	if size == 0 || size > s.maxSearchResults {
+		size = s.maxSearchResults
+	}
+
+	sorters, err := listingSorters(opts.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	source = source.Size(size).SortBy(sorters...)
+	if opts.SearchAfter != "" {
+		sortValues, err := decodeCursor(opts.SearchAfter, len(sorters))
+		if err != nil {
+			return nil, 0, errInvalidSearchAfterParameter
+		}
+		return source.SearchAfter(sortValues...), size, nil
+	}
+	return source.From(opts.From), size, nil
+}
+
+// listingSorters returns the sort of a type listing in the given order. The most annotated concepts are found with the
+// metrics, which only some types of concepts have, so the concepts without them are listed last.
+func listingSorters(order string) ([]elastic.Sorter, error) {
+	alphabetical := []elastic.Sorter{elastic.NewFieldSort("prefLabel.raw").Asc(), elastic.NewFieldSort("id").Asc()}
+	switch order {
+	case "", SortAlphabetical:
+		return alphabetical, nil
+	case SortPopularity:
+		popularity := elastic.NewFieldSort("metrics.annotationsCount").Desc().Missing(0).UnmappedType("integer")
+		return append([]elastic.Sorter{popularity}, alphabetical...), nil
+	}
+	return nil, errInvalidSortParameter
+}
+
+// nextCursor returns the cursor for the page following the given result, or an empty string if this was the last page.
+func nextCursor(result *elastic.SearchResult, size int) string {
+	if result.Hits == nil || len(result.Hits.Hits) == 0 || len(result.Hits.Hits) < size {
+		return ""
+	}
+	last := result.Hits.Hits[len(result.Hits.Hits)-1]
+	if len(last.Sort) == 0 {
+		return ""
+	}
+	return encodeCursor(last.Sort)
+}
+
+func encodeCursor(sortValues []interface{}) string {
+	b, err := json.Marshal(sortValues)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor reads the sort values of a cursor, which should be as many as the fields the listing is sorted on
+func decodeCursor(cursor string, sortFields int) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var sortValues []interface{}
+	if err := json.Unmarshal(b, &sortValues); err != nil {
+		return nil, err
+	}
+	if len(sortValues) != sortFields {
+		return nil, errInvalidSearchAfterParameter
+	}
+	return sortValues, nil
+}
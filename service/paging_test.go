@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := service.EncodeCursor([]interface{}{"Analysis", "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772"})
+
+	sortValues, err := service.DecodeCursor(cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"Analysis", "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772"}, sortValues)
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	_, err := service.DecodeCursor("not a cursor", 2)
+	assert.Error(t, err)
+
+	_, err = service.DecodeCursor(service.EncodeCursor([]interface{}{"Analysis"}), 2)
+	assert.Error(t, err)
+}
+
+func TestNextCursor(t *testing.T) {
+	result := &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			Hits: []*elastic.SearchHit{
+				{Sort: []interface{}{"Analysis", "1"}},
+				{Sort: []interface{}{"Comment", "2"}},
+			},
+		},
+	}
+
+	assert.Equal(t, service.EncodeCursor([]interface{}{"Comment", "2"}), service.NextCursor(result, 2), "a full page should have a cursor")
+	assert.Empty(t, service.NextCursor(result, 3), "the last page should not have a cursor")
+	assert.Empty(t, service.NextCursor(&elastic.SearchResult{}, 2), "no hits should not have a cursor")
+}
+
+func TestListingByPopularityWithInMemoryRepository(t *testing.T) {
+	repository := servicetest.NewInMemoryConceptRepository().
+		Add(testDefaultIndex, esGenreType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/2a88a647-59bc-4043-8f1b-5add71ddb3a0", PrefLabel: "Analysis", DirectType: ftGenreType},
+			service.EsConceptModel{Id: "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", PrefLabel: "Comment", DirectType: ftGenreType, Metrics: &service.ConceptMetrics{AnnotationsCount: 5}},
+			service.EsConceptModel{Id: "http://api.ft.com/things/a579350c-61ce-4c00-97ca-ddaa2e0cacf6", PrefLabel: "News", DirectType: ftGenreType, Metrics: &service.ConceptMetrics{AnnotationsCount: 12}},
+		)
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	result, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{Sort: service.SortPopularity, Size: 2})
+	require.NoError(t, err)
+	require.Len(t, result.Concepts, 2)
+	assert.Equal(t, "News", result.Concepts[0].PrefLabel, "the most annotated concepts should be listed first")
+	assert.Equal(t, "Comment", result.Concepts[1].PrefLabel)
+	assert.NotEmpty(t, result.SearchAfter)
+
+	result, err = svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{Sort: service.SortPopularity, Size: 2, SearchAfter: result.SearchAfter})
+	require.NoError(t, err)
+	require.Len(t, result.Concepts, 1)
+	assert.Equal(t, "Analysis", result.Concepts[0].PrefLabel, "the concepts without metrics should be listed last")
+
+	queries := repository.Queries()
+	require.Len(t, queries, 2)
+	assert.Contains(t, queryJSON(t, queries[0]), `"sort":[{"metrics.annotationsCount":{"missing":0,"order":"desc","unmapped_type":"integer"}},{"prefLabel.raw":{"order":"asc"}},{"id":{"order":"asc"}}]`)
+}
+
+func TestListingWithInvalidSort(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestInMemoryRepository(), testDefaultIndex, "", 10, 10)
+
+	_, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{Sort: "newest"})
+	assert.Equal(t, service.ErrInvalidSortParameter, err)
+
+	alphabeticalCursor := service.EncodeCursor([]interface{}{"Analysis", "http://api.ft.com/things/2a88a647-59bc-4043-8f1b-5add71ddb3a0"})
+	_, err = svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{Sort: service.SortPopularity, SearchAfter: alphabeticalCursor})
+	assert.Equal(t, service.ErrInvalidSearchAfterParameter, err, "the cursor of another order should be rejected")
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/util"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultRankingProfile is the name of the built-in ranking profile, used when no other default is configured.
+const DefaultRankingProfile = "default"
+
+// RankingProfile holds the weights used to rank the concepts found by a typeahead search.
+// The type weights are keyed by ES type, e.g. topics or people.
+type RankingProfile struct {
+	AliasesMatch           float64            `yaml:"aliasesMatch" json:"aliasesMatch"`
+	FuzzyPrefLabelMatch    float64            `yaml:"fuzzyPrefLabelMatch" json:"fuzzyPrefLabelMatch"`
+	FuzzyAliasesMatch      float64            `yaml:"fuzzyAliasesMatch" json:"fuzzyAliasesMatch"`
+	PrefLabelTermMatch     float64            `yaml:"prefLabelTermMatch" json:"prefLabelTermMatch"`
+	PrefLabelExactMatch    float64            `yaml:"prefLabelExactMatch" json:"prefLabelExactMatch"`
+	AliasesExactMatch      float64            `yaml:"aliasesExactMatch" json:"aliasesExactMatch"`
+	TypeBoosts             map[string]float64 `yaml:"typeBoosts" json:"typeBoosts"`
+	ScopeNote              float64            `yaml:"scopeNote" json:"scopeNote"`
+	PhraseMatch            float64            `yaml:"phraseMatch" json:"phraseMatch"`
+	PhraseMatchTypeWeights map[string]float64 `yaml:"phraseMatchTypeWeights" json:"phraseMatchTypeWeights"`
+	Popularity             float64            `yaml:"popularity" json:"popularity"`
+	LastWeekPopularity     float64            `yaml:"lastWeekPopularity" json:"lastWeekPopularity"`
+	Authors                float64            `yaml:"authors" json:"authors"`
+}
+
+// NewDefaultRankingProfile returns the built-in ranking profile, whose weights have been tuned over time
+// against the typeahead searches of the FT.
+func NewDefaultRankingProfile() RankingProfile {
+	return RankingProfile{
+		AliasesMatch:           0.8,
+		FuzzyPrefLabelMatch:    0.05,
+		FuzzyAliasesMatch:      0.04,
+		PrefLabelTermMatch:     0.1,
+		PrefLabelExactMatch:    15,
+		AliasesExactMatch:      0.85,
+		TypeBoosts:             map[string]float64{"topics": 1.5, "locations": 0.25, "people": 0.1},
+		ScopeNote:              1.7,
+		PhraseMatch:            4.5,
+		PhraseMatchTypeWeights: map[string]float64{"topics": 4.0},
+		Popularity:             1.5,
+		LastWeekPopularity:     1.5,
+		Authors:                1.8,
+	}
+}
+
+func (p RankingProfile) validate() error {
+	weights := []float64{p.AliasesMatch, p.FuzzyPrefLabelMatch, p.FuzzyAliasesMatch, p.PrefLabelTermMatch, p.PrefLabelExactMatch,
+		p.AliasesExactMatch, p.ScopeNote, p.PhraseMatch, p.Popularity, p.LastWeekPopularity, p.Authors}
+	for _, typeWeights := range []map[string]float64{p.TypeBoosts, p.PhraseMatchTypeWeights} {
+		for esType, w := range typeWeights {
+			if util.FtType(esType) == "" {
+				return fmt.Errorf("unknown ES type %v", esType)
+			}
+			weights = append(weights, w)
+		}
+	}
+	for _, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("negative weight %v", w)
+		}
+	}
+	return nil
+}
+
+// sortedTypes returns the ES types of the given weights in a stable order, so that a profile always leads to the same query
+func sortedTypes(typeWeights map[string]float64) []string {
+	esTypes := []string{}
+	for esType := range typeWeights {
+		esTypes = append(esTypes, esType)
+	}
+	sort.Strings(esTypes)
+	return esTypes
+}
+
+func mergeTypeWeights(defaults map[string]float64, overrides map[string]float64) map[string]float64 {
+	for esType, w := range overrides {
+		defaults[esType] = w
+	}
+	return defaults
+}
+
+// rankingConfig is the layout of the ranking configuration file, which can be either YAML or JSON.
+// The weights which are left out of a profile are the ones of the built-in profile.
+type rankingConfig struct {
+	Default  string                 `yaml:"default"`
+	Profiles map[string]interface{} `yaml:"profiles"`
+}
+
+// RankingProfiles holds the named ranking profiles the searches can pick from. When loaded from a file,
+// they can be reloaded at any time; the searches in progress keep the profile they started with.
+type RankingProfiles struct {
+	lock        *sync.RWMutex
+	path        string
+	modTime     time.Time
+	defaultName string
+	profiles    map[string]RankingProfile
+	onReload    []func()
+}
+
+// NewRankingProfiles returns the built-in ranking profile only.
+func NewRankingProfiles() *RankingProfiles {
+	return &RankingProfiles{
+		lock:        &sync.RWMutex{},
+		defaultName: DefaultRankingProfile,
+		profiles:    map[string]RankingProfile{DefaultRankingProfile: NewDefaultRankingProfile()},
+	}
+}
+
+// LoadRankingProfiles returns the ranking profiles of the given configuration file, along with the built-in profile.
+func LoadRankingProfiles(path string) (*RankingProfiles, error) {
+	profiles := NewRankingProfiles()
+	profiles.path = path
+	if err := profiles.Reload(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// Get returns the named profile, or the default one (along with its name) for an empty name.
+// Unknown profiles are an InputError.
+func (p *RankingProfiles) Get(name string) (RankingProfile, string, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if name == "" {
+		name = p.defaultName
+	}
+	profile, found := p.profiles[name]
+	if !found {
+		return RankingProfile{}, name, util.NewParameterErrorf(util.ErrCodeInvalidParameter, "profile", "unknown ranking profile %v", name)
+	}
+	return profile, name, nil
+}
+
+// Names returns the names of the available profiles, sorted, along with the name of the default one.
+func (p *RankingProfiles) Names() ([]string, string) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	names := []string{}
+	for name := range p.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, p.defaultName
+}
+
+// OnReload registers a function to be called whenever the profiles have been reloaded.
+func (p *RankingProfiles) OnReload(f func()) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.onReload = append(p.onReload, f)
+}
+
+// Reload reads the configuration file again. Should it be invalid, the current profiles are kept.
+func (p *RankingProfiles) Reload() error {
+	if p.path == "" {
+		return nil
+	}
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	defaultName, profiles, err := parseRankingConfig(data)
+	if err != nil {
+		return fmt.Errorf("invalid ranking configuration %v: %v", p.path, err)
+	}
+
+	p.lock.Lock()
+	p.modTime = info.ModTime()
+	p.defaultName = defaultName
+	p.profiles = profiles
+	onReload := append([]func(){}, p.onReload...)
+	p.lock.Unlock()
+
+	for _, f := range onReload {
+		f()
+	}
+	return nil
+}
+
+// Watch reloads the configuration file whenever it has been modified, checking for changes at the given interval
+// until the stop channel is closed.
+func (p *RankingProfiles) Watch(interval time.Duration, stop <-chan struct{}) {
+	if p.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.reloadIfModified()
+		}
+	}
+}
+
+func (p *RankingProfiles) reloadIfModified() {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		log.WithError(err).Warn("could not check the ranking configuration for changes")
+		return
+	}
+	p.lock.RLock()
+	modified := !info.ModTime().Equal(p.modTime)
+	p.lock.RUnlock()
+	if !modified {
+		return
+	}
+
+	if err := p.Reload(); err != nil {
+		log.WithError(err).Error("could not reload the ranking configuration, the previous profiles are still in use")
+		return
+	}
+	names, defaultName := p.Names()
+	log.Infof("reloaded the ranking profiles %v, using %v by default", names, defaultName)
+}
+
+func parseRankingConfig(data []byte) (string, map[string]RankingProfile, error) {
+	config := rankingConfig{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return "", nil, err
+	}
+
+	profiles := map[string]RankingProfile{DefaultRankingProfile: NewDefaultRankingProfile()}
+	for name, values := range config.Profiles {
+		profile := NewDefaultRankingProfile()
+		if values != nil {
+			// the weights of the file are decoded over the built-in ones; the type weights are merged afterwards,
+			// as strict decoding rejects the keys already set in a map
+			raw, err := yaml.Marshal(values)
+			if err != nil {
+				return "", nil, err
+			}
+			typeBoosts, phraseMatchTypeWeights := profile.TypeBoosts, profile.PhraseMatchTypeWeights
+			profile.TypeBoosts, profile.PhraseMatchTypeWeights = nil, nil
+			if err := yaml.UnmarshalStrict(raw, &profile); err != nil {
+				return "", nil, fmt.Errorf("profile %v: %v", name, err)
+			}
+			profile.TypeBoosts = mergeTypeWeights(typeBoosts, profile.TypeBoosts)
+			profile.PhraseMatchTypeWeights = mergeTypeWeights(phraseMatchTypeWeights, profile.PhraseMatchTypeWeights)
+		}
+		if err := profile.validate(); err != nil {
+			return "", nil, fmt.Errorf("profile %v: %v", name, err)
+		}
+		profiles[name] = profile
+	}
+
+	defaultName := config.Default
+	if defaultName == "" {
+		defaultName = DefaultRankingProfile
+	}
+	if _, found := profiles[defaultName]; !found {
+		return "", nil, fmt.Errorf("unknown default profile %v", defaultName)
+	}
+	return defaultName, profiles, nil
+}
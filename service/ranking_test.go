@@ -0,0 +1,244 @@
+package service_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRankingConfigYAML = `
+default: popular
+profiles:
+  popular:
+    popularity: 3
+    lastWeekPopularity: 2.5
+    typeBoosts:
+      topics: 2
+  no-types:
+    typeBoosts:
+      topics: 0
+      locations: 0
+      people: 0
+    phraseMatchTypeWeights: {}
+`
+
+const testRankingConfigJSON = `{
+  "profiles": {
+    "exact": {"prefLabelExactMatch": 30, "authors": 3}
+  }
+}`
+
+func writeRankingConfig(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ranking")
+	require.NoError(t, err)
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestBuiltInRankingProfile(t *testing.T) {
+	profiles := service.NewRankingProfiles()
+
+	profile, name, err := profiles.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, service.DefaultRankingProfile, name)
+	assert.Equal(t, service.NewDefaultRankingProfile(), profile)
+
+	_, _, err = profiles.Get("popular")
+	assert.Equal(t, util.NewParameterError(util.ErrCodeInvalidParameter, "profile", "unknown ranking profile popular"), err)
+}
+
+func TestLoadRankingProfilesYAML(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	profiles, err := service.LoadRankingProfiles(writeRankingConfig(t, dir, "ranking.yml", testRankingConfigYAML))
+	require.NoError(t, err)
+
+	names, defaultName := profiles.Names()
+	assert.Equal(t, []string{"default", "no-types", "popular"}, names)
+	assert.Equal(t, "popular", defaultName)
+
+	profile, name, err := profiles.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, "popular", name)
+	expected := service.NewDefaultRankingProfile()
+	expected.Popularity = 3
+	expected.LastWeekPopularity = 2.5
+	expected.TypeBoosts["topics"] = 2
+	assert.Equal(t, expected, profile, "the weights left out should be the built-in ones")
+
+	profile, _, err = profiles.Get("no-types")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"topics": 0, "locations": 0, "people": 0}, profile.TypeBoosts)
+	assert.Equal(t, map[string]float64{"topics": 4}, profile.PhraseMatchTypeWeights, "type weights are merged into the built-in ones")
+
+	profile, _, err = profiles.Get(service.DefaultRankingProfile)
+	require.NoError(t, err)
+	assert.Equal(t, service.NewDefaultRankingProfile(), profile, "the built-in profile should still be available")
+}
+
+func TestLoadRankingProfilesJSON(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	profiles, err := service.LoadRankingProfiles(writeRankingConfig(t, dir, "ranking.json", testRankingConfigJSON))
+	require.NoError(t, err)
+
+	_, name, err := profiles.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, service.DefaultRankingProfile, name)
+
+	profile, _, err := profiles.Get("exact")
+	require.NoError(t, err)
+	assert.Equal(t, 30.0, profile.PrefLabelExactMatch)
+	assert.Equal(t, 3.0, profile.Authors)
+	assert.Equal(t, 0.85, profile.AliasesExactMatch)
+}
+
+func TestLoadInvalidRankingProfiles(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+
+	testCases := []struct {
+		name   string
+		config string
+	}{
+		{"unknown weight", "profiles:\n  typo:\n    popularty: 2\n"},
+		{"unknown type", "profiles:\n  typo:\n    typeBoosts:\n      tardigrades: 2\n"},
+		{"negative weight", "profiles:\n  negative:\n    scopeNote: -1\n"},
+		{"unknown default", "default: missing\nprofiles:\n  popular:\n    popularity: 3\n"},
+		{"not a number", "profiles:\n  popular:\n    popularity: lots\n"},
+		{"unknown setting", "defaults: popular\n"},
+		{"malformed", "{profiles: "},
+	}
+
+	for _, tc := range testCases {
+		_, err := service.LoadRankingProfiles(writeRankingConfig(t, dir, "ranking.yml", tc.config))
+		assert.Error(t, err, tc.name)
+	}
+
+	_, err := service.LoadRankingProfiles(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err, "missing file")
+}
+
+func TestReloadRankingProfiles(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+	path := writeRankingConfig(t, dir, "ranking.yml", testRankingConfigJSON)
+
+	profiles, err := service.LoadRankingProfiles(path)
+	require.NoError(t, err)
+	reloaded := 0
+	profiles.OnReload(func() { reloaded++ })
+
+	profiles.ReloadIfModified()
+	assert.Equal(t, 0, reloaded, "the profiles should not be reloaded when the file has not changed")
+
+	writeRankingConfig(t, dir, "ranking.yml", testRankingConfigYAML)
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	profiles.ReloadIfModified()
+	assert.Equal(t, 1, reloaded)
+	_, name, err := profiles.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, "popular", name)
+
+	writeRankingConfig(t, dir, "ranking.yml", "profiles:\n  typo:\n    popularty: 2\n")
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	profiles.ReloadIfModified()
+	assert.Equal(t, 1, reloaded, "an invalid file should not be applied")
+	_, _, err = profiles.Get("popular")
+	assert.NoError(t, err, "the previous profiles should still be in use")
+}
+
+func TestWatchRankingProfiles(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+	path := writeRankingConfig(t, dir, "ranking.yml", testRankingConfigJSON)
+
+	profiles, err := service.LoadRankingProfiles(path)
+	require.NoError(t, err)
+	reloaded := make(chan struct{}, 1)
+	profiles.OnReload(func() { reloaded <- struct{}{} })
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go profiles.Watch(10*time.Millisecond, stop)
+
+	writeRankingConfig(t, dir, "ranking.yml", testRankingConfigYAML)
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("the modified configuration should have been reloaded")
+	}
+	_, name, err := profiles.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, "popular", name)
+}
+
+func TestSearchWithRankingProfile(t *testing.T) {
+	dir, cleanup := newTestDir(t)
+	defer cleanup()
+	profiles, err := service.LoadRankingProfiles(writeRankingConfig(t, dir, "ranking.yml", testRankingConfigYAML))
+	require.NoError(t, err)
+
+	repository := newTestInMemoryRepository()
+	svc := service.NewRankedConceptSearchService(repository, profiles, testDefaultIndex, "", 10, 10)
+
+	result, err := svc.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftGenreType}, service.SearchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "popular", result.Profile)
+
+	result, err = svc.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftPeopleType}, "authors", service.SearchOptions{Profile: "no-types"})
+	require.NoError(t, err)
+	assert.Equal(t, "no-types", result.Profile)
+
+	_, err = svc.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftGenreType}, service.SearchOptions{Profile: "missing"})
+	assert.Equal(t, util.NewParameterError(util.ErrCodeInvalidParameter, "profile", "unknown ranking profile missing"), err)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 2)
+	popular := queryJSON(t, queries[0])
+	assert.Contains(t, popular, `{"term":{"_type":{"_name":"typeBoost.topics","boost":2,"value":"topics"}}}`)
+	assert.Contains(t, popular, `{"bool":{"_name":"popularity","must":{"function_score":{"boost":3,"functions":[{"field_value_factor":{"field":"metrics.annotationsCount"`)
+	assert.Contains(t, popular, `{"bool":{"_name":"lastWeekPopularity","must":{"function_score":{"boost":2.5,"functions":[{"field_value_factor":{"field":"metrics.prevWeekAnnotationsCount"`)
+	noTypes := queryJSON(t, queries[1])
+	assert.Contains(t, noTypes, `{"term":{"_type":{"_name":"typeBoost.people","boost":0,"value":"people"}}}`)
+	assert.Contains(t, noTypes, `{"term":{"isFTAuthor":{"_name":"authors","boost":1.8,"value":"true"}}}`)
+}
+
+func TestSearchWithBuiltInRankingProfile(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	result, err := svc.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftGenreType}, service.SearchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, service.DefaultRankingProfile, result.Profile)
+
+	query := queryJSON(t, repository.Queries()[0])
+	for _, weight := range []string{
+		`{"match":{"prefLabel.exact_match":{"_name":"exactMatch","boost":15,"query":"test"}}}`,
+		`{"term":{"_type":{"_name":"typeBoost.topics","boost":1.5,"value":"topics"}}}`,
+		`{"term":{"_type":{"_name":"typeBoost.locations","boost":0.25,"value":"locations"}}}`,
+		`{"term":{"_type":{"_name":"typeBoost.people","boost":0.1,"value":"people"}}}`,
+		`{"bool":{"_name":"scopeNote","boost":1.7,"must":{"exists":{"field":"scopeNote"}}}}`,
+		`{"weight":4.5}`,
+		`{"filter":{"term":{"_type":"topics"}},"weight":4}`,
+	} {
+		assert.Contains(t, query, weight, "the built-in profile should hold the historical weights")
+	}
+}
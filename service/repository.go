@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/util"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const scrollKeepAlive = "1m"
+
+// SearchQuery describes a search to be run against the concepts, along with the index and types it targets.
+type SearchQuery struct {
+	Index      string
+	Types      []string
+	SearchType string
+	Source     *elastic.SearchSource
+}
+
+// ConceptRepository is the access layer to the concepts stored in Elasticsearch.
+// Every call takes the context of the request it is made for, so that its cancellation and deadline reach the cluster.
+type ConceptRepository interface {
+	ESService
+	Search(ctx context.Context, query SearchQuery) (*elastic.SearchResult, error)
+	MultiSearch(ctx context.Context, index string, requests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error)
+	Scroll(ctx context.Context, query SearchQuery, handle func(*elastic.SearchResult) error) error
+	MultiGet(ctx context.Context, index string, ids []string) (*elastic.MgetResponse, error)
+	ClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error)
+}
+
+type esConceptRepository struct {
+	esClient     *elastic.Client
+	clientLock   *sync.RWMutex
+	queryTimeout time.Duration
+}
+
+// NewEsConceptRepository returns a ConceptRepository backed by Elasticsearch, which fails with
+// util.ErrNoElasticClient until its client has been set. Every query sent to the cluster is given up on
+// after the query timeout (if positive) with util.ErrQueryTimeout.
+func NewEsConceptRepository(queryTimeout time.Duration) ConceptRepository {
+	return &esConceptRepository{clientLock: &sync.RWMutex{}, queryTimeout: queryTimeout}
+}
+
+func (r *esConceptRepository) SetElasticClient(client *elastic.Client) {
+	r.clientLock.Lock()
+	defer r.clientLock.Unlock()
+	r.esClient = client
+}
+
+func (r *esConceptRepository) elasticClient() (*elastic.Client, error) {
+	r.clientLock.RLock()
+	defer r.clientLock.RUnlock()
+	if r.esClient == nil {
+		return nil, util.ErrNoElasticClient
+	}
+	return r.esClient, nil
+}
+
+func (r *esConceptRepository) Search(ctx context.Context, query SearchQuery) (*elastic.SearchResult, error) {
+	client, err := r.elasticClient()
+	if err != nil {
+		return nil, err
+	}
+
+	search := client.Search(query.Index).SearchSource(query.Source)
+	if len(query.Types) > 0 {
+		search = search.Type(query.Types...)
+	}
+	if query.SearchType != "" {
+		search = search.SearchType(query.SearchType)
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	result, err := search.Do(ctx)
+	return result, queryError(ctx, err)
+}
+
+func (r *esConceptRepository) MultiSearch(ctx context.Context, index string, requests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
+	client, err := r.elasticClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	result, err := client.MultiSearch().Index(index).Add(requests...).Do(ctx)
+	return result, queryError(ctx, err)
+}
+
+// Scroll hands over the results of the query one batch at a time, the size of the batches being the one of the query.
+// It stops at the first error returned by the handle function. The query timeout applies to each batch.
+func (r *esConceptRepository) Scroll(ctx context.Context, query SearchQuery, handle func(*elastic.SearchResult) error) error {
+	client, err := r.elasticClient()
+	if err != nil {
+		return err
+	}
+
+	scroll := client.Scroll(query.Index).SearchSource(query.Source).KeepAlive(scrollKeepAlive)
+	if len(query.Types) > 0 {
+		scroll = scroll.Type(query.Types...)
+	}
+	// the scroll has to be released even when the request it was made for has been cancelled
+	defer scroll.Clear(context.Background())
+
+	for {
+		result, err := r.nextBatch(ctx, scroll)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := handle(result); err != nil {
+			return err
+		}
+	}
+}
+
+// MultiGet fetches the concepts of the index with the given ES ids, whatever their type, in a single request.
+func (r *esConceptRepository) MultiGet(ctx context.Context, index string, ids []string) (*elastic.MgetResponse, error) {
+	client, err := r.elasticClient()
+	if err != nil {
+		return nil, err
+	}
+
+	mget := client.MultiGet()
+	for _, id := range ids {
+		mget = mget.Add(elastic.NewMultiGetItem().Index(index).Id(id))
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	result, err := mget.Do(ctx)
+	return result, queryError(ctx, err)
+}
+
+func (r *esConceptRepository) ClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error) {
+	client, err := r.elasticClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	health, err := client.ClusterHealth().Do(ctx)
+	return health, queryError(ctx, err)
+}
+
+func (r *esConceptRepository) nextBatch(ctx context.Context, scroll *elastic.ScrollService) (*elastic.SearchResult, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	result, err := scroll.Do(ctx)
+	if err == io.EOF {
+		return nil, err
+	}
+	return result, queryError(ctx, err)
+}
+
+func (r *esConceptRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// queryError reports the queries which ran out of time as timeouts, whatever the way the ES client failed them
+func queryError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return util.ErrQueryTimeout
+	}
+	return err
+}
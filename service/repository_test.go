@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+// the external tests of the package have their own copy of the fixtures of search_test.go
+const (
+	testDefaultIndex   = "test-index"
+	testExtendedIndex  = "test-extended-index"
+	esGenreType        = "genres"
+	esBrandType        = "brands"
+	esPeopleType       = "people"
+	esOrganisationType = "organisations"
+	ftGenreType        = "http://www.ft.com/ontology/Genre"
+	ftBrandType        = "http://www.ft.com/ontology/product/Brand"
+	ftPeopleType       = "http://www.ft.com/ontology/person/Person"
+	ftOrganisationType = "http://www.ft.com/ontology/organisation/Organisation"
+	ftPublicCompanies  = "http://www.ft.com/ontology/company/PublicCompany"
+)
+
+func newTestInMemoryRepository() *servicetest.InMemoryConceptRepository {
+	return servicetest.NewInMemoryConceptRepository().
+		Add(testDefaultIndex, esGenreType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/2a88a647-59bc-4043-8f1b-5add71ddb3a0", PrefLabel: "Analysis", DirectType: ftGenreType},
+			service.EsConceptModel{Id: "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", PrefLabel: "Comment", DirectType: ftGenreType},
+		).
+		Add(testDefaultIndex, esBrandType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/40f636a3-5507-4311-9629-95376007cb7b", PrefLabel: "Lex", DirectType: ftBrandType},
+		)
+}
+
+func TestEsConceptRepositoryWithoutClient(t *testing.T) {
+	repository := service.NewEsConceptRepository(time.Second)
+
+	_, err := repository.Search(context.Background(), service.SearchQuery{Index: testDefaultIndex, Source: elastic.NewSearchSource()})
+	assert.Equal(t, util.ErrNoElasticClient, err)
+
+	_, err = repository.MultiSearch(context.Background(), testDefaultIndex)
+	assert.Equal(t, util.ErrNoElasticClient, err)
+
+	err = repository.Scroll(context.Background(), service.SearchQuery{Index: testDefaultIndex, Source: elastic.NewSearchSource()}, func(*elastic.SearchResult) error { return nil })
+	assert.Equal(t, util.ErrNoElasticClient, err)
+
+	_, err = repository.ClusterHealth(context.Background())
+	assert.Equal(t, util.ErrNoElasticClient, err)
+}
+
+func TestEsConceptRepositoryQueryTimeout(t *testing.T) {
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hits": {"total": 0, "hits": []}}`))
+	}))
+	defer es.Close()
+
+	client, err := elastic.NewClient(elastic.SetURL(es.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	require.NoError(t, err)
+
+	repository := service.NewEsConceptRepository(50 * time.Millisecond)
+	repository.SetElasticClient(client)
+
+	_, err = repository.Search(context.Background(), service.SearchQuery{Index: testDefaultIndex, Source: elastic.NewSearchSource()})
+	assert.Equal(t, util.ErrQueryTimeout, err)
+
+	_, err = repository.ClusterHealth(context.Background())
+	assert.Equal(t, util.ErrQueryTimeout, err)
+}
+
+func TestListingWithInMemoryRepository(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestInMemoryRepository(), testDefaultIndex, "", 1, 10)
+
+	result, err := svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Concepts, 1)
+	assert.Equal(t, "Analysis", result.Concepts[0].PrefLabel)
+	assert.Equal(t, int64(2), result.Total)
+	assert.NotEmpty(t, result.SearchAfter)
+
+	result, err = svc.FindAllConceptsByType(context.Background(), ftGenreType, service.ListOptions{SearchAfter: result.SearchAfter})
+	require.NoError(t, err)
+	require.Len(t, result.Concepts, 1)
+	assert.Equal(t, "Comment", result.Concepts[0].PrefLabel)
+}
+
+func TestFindConceptByUUIDWithInMemoryRepository(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestInMemoryRepository(), testDefaultIndex, "", 10, 10)
+
+	concept, err := svc.FindConceptByUUID(context.Background(), "40f636a3-5507-4311-9629-95376007cb7b", false)
+	require.NoError(t, err)
+	assert.Equal(t, "http://www.ft.com/thing/40f636a3-5507-4311-9629-95376007cb7b", concept.Id)
+	assert.Equal(t, "Lex", concept.PrefLabel)
+
+	_, err = svc.FindConceptByUUID(context.Background(), "0c2e6e26-9b02-4d58-9c4c-5fb4fcbd9bc3", false)
+	assert.Equal(t, util.ErrConceptNotFound, err)
+}
+
+func TestExportWithInMemoryRepository(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	exported := []string{}
+	err := svc.ExportConceptsByType(context.Background(), ftGenreType, service.ListOptions{}, func(c service.EsConceptModel) error {
+		exported = append(exported, c.PrefLabel)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Analysis", "Comment"}, exported)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 1)
+	assert.Equal(t, []string{esGenreType}, queries[0].Types)
+}
+
+func queryJSON(t *testing.T, query service.SearchQuery) string {
+	source, err := query.Source.Source()
+	require.NoError(t, err)
+	body, err := json.Marshal(source)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestListingBySubtypeWithInMemoryRepository(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	_, err := svc.FindAllConceptsByType(context.Background(), ftOrganisationType, service.ListOptions{})
+	require.NoError(t, err)
+	_, err = svc.FindAllConceptsByType(context.Background(), ftPublicCompanies, service.ListOptions{})
+	require.NoError(t, err)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 2)
+	assert.Equal(t, []string{esOrganisationType}, queries[0].Types)
+	assert.NotContains(t, queryJSON(t, queries[0]), `"types"`, "the subtypes should be part of the listing of their parent type")
+	assert.Equal(t, []string{esOrganisationType}, queries[1].Types, "subtypes should be looked for under the ES type of their parent")
+	assert.Contains(t, queryJSON(t, queries[1]), `{"term":{"types":"http://www.ft.com/ontology/company/PublicCompany"}}`)
+}
+
+func TestSearchBySubtypeWithInMemoryRepository(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, "", 10, 10)
+
+	_, err := svc.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftBrandType, ftPublicCompanies, ftPeopleType}, service.SearchOptions{})
+	require.NoError(t, err)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 1)
+	query := queryJSON(t, queries[0])
+	assert.Contains(t, query, `{"bool":{"filter":[{"term":{"_type":"organisations"}},{"term":{"types":"http://www.ft.com/ontology/company/PublicCompany"}}]}}`)
+	assert.Contains(t, query, `{"terms":{"_type":["brands","people"]}}`)
+	assert.NotContains(t, query, "directType", "subtypes should not be special cased")
+
+	_, err = svc.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftPublicCompanies, "http://www.ft.com/ontology/Foo"}, service.SearchOptions{})
+	assert.Equal(t, util.NewParameterError(util.ErrCodeInvalidParameter, "type", "invalid concept type http://www.ft.com/ontology/Foo"), err)
+}
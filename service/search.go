@@ -3,8 +3,6 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"sort"
-	"sync"
 	"time"
 
 	"github.com/Financial-Times/concept-search-api/util"
@@ -14,122 +12,163 @@ import (
 )
 
 var (
-	errEmptyTextParameter = util.NewInputError("empty text parameter")
-	errEmptyIdsParameter  = util.NewInputError("empty Ids parameter")
+	errEmptyTextParameter = util.NewParameterError(util.ErrCodeMissingParameter, "q", "empty text parameter")
+	errEmptyIdsParameter  = util.NewParameterError(util.ErrCodeMissingParameter, "ids", "empty Ids parameter")
 
 	mentionTypes = []string{"http://www.ft.com/ontology/person/Person", "http://www.ft.com/ontology/organisation/Organisation", "http://www.ft.com/ontology/Location", "http://www.ft.com/ontology/Topic"}
 )
 
 type ConceptSearchService interface {
 	SetElasticClient(client *elastic.Client)
-	FindConceptsById(ids []string) ([]Concept, error)
-	FindAllConceptsByType(conceptType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error)
-	FindAllConceptsByDirectType(conceptType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error)
-	SearchConceptByTextAndTypes(textQuery string, conceptTypes []string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error)
-	SearchConceptByTextAndTypesWithBoost(textQuery string, conceptTypes []string, boostType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error)
+	FindConceptsById(ctx context.Context, ids []string) (SearchResult, error)
+	FindConceptByUUID(ctx context.Context, uuid string, searchAllAuthorities bool) (EsConceptModel, error)
+	LookupConcepts(ctx context.Context, ids []string) (LookupResult, error)
+	FindAllConceptsByType(ctx context.Context, conceptType string, opts ListOptions) (SearchResult, error)
+	ExportConceptsByType(ctx context.Context, conceptType string, opts ListOptions, handle func(EsConceptModel) error) error
+	SearchConceptByTextAndTypes(ctx context.Context, textQuery string, conceptTypes []string, opts SearchOptions) (SearchResult, error)
+	SearchConceptByTextAndTypesWithBoost(ctx context.Context, textQuery string, conceptTypes []string, boostType string, opts SearchOptions) (SearchResult, error)
+	SearchConceptsByTerm(ctx context.Context, term string, opts TermSearchOptions) (TermSearchResult, error)
+	FindBestMatchingConcepts(ctx context.Context, terms []string, opts TermSearchOptions) (map[string]*ScoredConcept, error)
 }
 
 type esConceptSearchService struct {
-	esClient               *elastic.Client
+	repository             ConceptRepository
+	ranking                *RankingProfiles
 	defaultIndex           string
 	extendedSearchIndex    string
 	maxSearchResults       int
 	maxAutoCompleteResults int
 	mappingRefreshTicker   *time.Ticker
 	mappingRefreshInterval time.Duration
-	authorsBoost           int
-	clientLock             *sync.RWMutex
 }
 
-func NewEsConceptSearchService(defaultIndex string, extendedSearchIndex string, maxSearchResults int, maxAutoCompleteResults int, authorsBoost int) ConceptSearchService {
+// NewEsConceptSearchService returns a ConceptSearchService backed by its own Elasticsearch repository, without query timeout.
+func NewEsConceptSearchService(defaultIndex string, extendedSearchIndex string, maxSearchResults int, maxAutoCompleteResults int) ConceptSearchService {
+	return NewConceptSearchService(NewEsConceptRepository(0), defaultIndex, extendedSearchIndex, maxSearchResults, maxAutoCompleteResults)
+}
+
+// NewConceptSearchService returns a ConceptSearchService querying the given repository, which may be shared with other services.
+// Searches are ranked with the built-in profile.
+func NewConceptSearchService(repository ConceptRepository, defaultIndex string, extendedSearchIndex string, maxSearchResults int, maxAutoCompleteResults int) ConceptSearchService {
+	return NewRankedConceptSearchService(repository, NewRankingProfiles(), defaultIndex, extendedSearchIndex, maxSearchResults, maxAutoCompleteResults)
+}
+
+// NewRankedConceptSearchService returns a ConceptSearchService querying the given repository, whose searches are ranked
+// according to the given profiles.
+func NewRankedConceptSearchService(repository ConceptRepository, ranking *RankingProfiles, defaultIndex string, extendedSearchIndex string, maxSearchResults int, maxAutoCompleteResults int) ConceptSearchService {
 	return &esConceptSearchService{
+		repository:             repository,
+		ranking:                ranking,
 		defaultIndex:           defaultIndex,
 		extendedSearchIndex:    extendedSearchIndex,
 		maxSearchResults:       maxSearchResults,
 		maxAutoCompleteResults: maxAutoCompleteResults,
-		authorsBoost:           authorsBoost,
-		clientLock:             &sync.RWMutex{},
 	}
 }
 
-func (s *esConceptSearchService) checkElasticClient() error {
-	if s.elasticClient() == nil {
-		return util.ErrNoElasticClient
+func (s *esConceptSearchService) FindAllConceptsByType(ctx context.Context, conceptType string, opts ListOptions) (SearchResult, error) {
+	filter, err := util.NewConceptTypeFilter(conceptType)
+	if err != nil {
+		return SearchResult{}, err
 	}
-	return nil
-}
 
-func (s *esConceptSearchService) FindAllConceptsByType(conceptType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error) {
-	t := util.EsType(conceptType)
-	if t == "" {
-		return nil, util.NewInputErrorf(util.ErrInvalidConceptTypeFormat, conceptType)
-	}
+	boolQuery := addListFilters(addSubtypeFilter(elastic.NewBoolQuery(), filter), opts)
 
-	if err := s.checkElasticClient(); err != nil {
-		return nil, err
-	}
+	index := s.getIndexForAuthoritiesParam(opts.SearchAllAuthorities)
+	return s.findAllConcepts(ctx, SearchQuery{Index: index, Types: []string{filter.EsType}, Source: fetchFields(elastic.NewSearchSource().Query(boolQuery), opts.Fields)}, opts)
+}
 
-	index := s.getIndexForAuthoritiesParam(searchAllAuthorities)
-	query := s.esClient.Search(index).Type(t).Size(s.maxSearchResults)
-	if !includeDeprecated {
-		deprecatedQ := elastic.NewBoolQuery().MustNot(elastic.NewTermQuery("isDeprecated", true))
-		query = query.Query(deprecatedQ)
+func (s *esConceptSearchService) findAllConcepts(ctx context.Context, query SearchQuery, opts ListOptions) (SearchResult, error) {
+	source, size, err := s.paginate(query.Source, opts)
+	if err != nil {
+		return SearchResult{}, err
 	}
+	query.Source = source
 
-	result, err := query.Do(context.Background())
+	result, err := s.repository.Search(ctx, query)
 	if err != nil {
 		log.Errorf("error: %v", err)
-		return nil, err
+		return SearchResult{}, err
 	}
-	concepts := searchResultToConcepts(result)
-	sort.Sort(concepts)
-	return concepts, nil
+	searchResult := newSearchResult(result, query.Index, size, returnedFields(opts.Fields, opts.IncludeMetrics))
+	searchResult.SearchAfter = nextCursor(result, size)
+	return searchResult, nil
 }
 
-func (s *esConceptSearchService) FindAllConceptsByDirectType(conceptType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error) {
-	boolQuery := elastic.NewBoolQuery()
-	boolQuery.Must(elastic.NewMatchQuery("directType", conceptType))
-
-	if !includeDeprecated {
-		boolQuery.MustNot(elastic.NewTermQuery("isDeprecated", true))
+func (s *esConceptSearchService) FindConceptsById(ctx context.Context, ids []string) (SearchResult, error) {
+	if ids == nil || len(ids) == 0 || containsOnlyEmptyValues(ids) {
+		return SearchResult{}, errEmptyIdsParameter
 	}
-
-	index := s.getIndexForAuthoritiesParam(searchAllAuthorities)
-	result, err := s.esClient.Search(index).Size(s.maxSearchResults).Query(boolQuery).Do(context.Background())
+	uuids, err := util.ConceptUUIDs(ids)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	idsQuery := elastic.NewIdsQuery("_all").Ids(uuids...)
+	source := elastic.NewSearchSource().Size(s.maxSearchResults).Query(idsQuery)
+	result, err := s.repository.Search(ctx, SearchQuery{Index: s.defaultIndex, Source: source})
 	if err != nil {
 		log.Errorf("error: %v", err)
-		return nil, err
+		return SearchResult{}, err
 	}
-	concepts := searchResultToConcepts(result)
-	sort.Sort(concepts)
-	return concepts, nil
+	return newSearchResult(result, s.defaultIndex, s.maxSearchResults, nil), nil
 }
 
-func (s *esConceptSearchService) FindConceptsById(ids []string) ([]Concept, error) {
-	if ids == nil || len(ids) == 0 || containsOnlyEmptyValues(ids) {
-		return nil, errEmptyIdsParameter
+func (s *esConceptSearchService) FindConceptByUUID(ctx context.Context, uuid string, searchAllAuthorities bool) (EsConceptModel, error) {
+	if uuid == "" {
+		return EsConceptModel{}, errEmptyIdsParameter
 	}
-	if err := s.checkElasticClient(); err != nil {
-		return nil, err
-	}
-	idsQuery := elastic.NewIdsQuery("_all").Ids(ids...)
-	result, err := s.esClient.Search(s.defaultIndex).Size(s.maxSearchResults).Query(idsQuery).Do(context.Background())
+	index := s.getIndexForAuthoritiesParam(searchAllAuthorities)
+	idsQuery := elastic.NewIdsQuery("_all").Ids(uuid)
+	source := elastic.NewSearchSource().Size(1).Query(idsQuery)
+	result, err := s.repository.Search(ctx, SearchQuery{Index: index, Source: source})
 	if err != nil {
 		log.Errorf("error: %v", err)
-		return nil, err
+		return EsConceptModel{}, err
+	}
+	if result.Hits == nil || len(result.Hits.Hits) == 0 {
+		return EsConceptModel{}, util.ErrConceptNotFound
+	}
+
+	esConcept := EsConceptModel{}
+	if err := json.Unmarshal(*result.Hits.Hits[0].Source, &esConcept); err != nil {
+		log.Warnf("unmarshallable response from ElasticSearch: %v", err)
+		return EsConceptModel{}, err
+	}
+	esConcept.Id = correctPath(esConcept.Id)
+	return esConcept, nil
+}
+
+// newSearchResult reads the concepts out of the hits of a search, along with the given fields which are not returned
+// by default (aliases, metrics and score)
+func newSearchResult(result *elastic.SearchResult, index string, limit int, fields []string) SearchResult {
+	return SearchResult{
+		Concepts: searchResultToConcepts(result, fields),
+		Total:    result.TotalHits(),
+		Took:     result.TookInMillis,
+		Index:    index,
+		Limit:    limit,
 	}
-	concepts := searchResultToConcepts(result)
-	return concepts, nil
 }
 
-func searchResultToConcepts(result *elastic.SearchResult) Concepts {
+func searchResultToConcepts(result *elastic.SearchResult, fields []string) Concepts {
 	concepts := Concepts{}
 	for _, c := range result.Hits.Hits {
-		concept, err := transformToConcept(c.Source)
-		if err != nil {
+		esConcept := EsConceptModel{}
+		if err := json.Unmarshal(*c.Source, &esConcept); err != nil {
 			log.Warnf("unmarshallable response from ElasticSearch: %v", err)
 			continue
 		}
+		concept := ConvertToSimpleConcept(esConcept)
+		if hasField(fields, "aliases") {
+			concept.Aliases = esConcept.Aliases
+		}
+		if hasField(fields, "metrics") {
+			concept.Metrics = esConcept.Metrics
+		}
+		if hasField(fields, "score") && c.Score != nil {
+			concept.Score = *c.Score
+		}
+		concept.Highlight = HighlightFromHit(c)
 		concepts = append(concepts, concept)
 	}
 	return concepts
@@ -144,56 +183,72 @@ func transformToConcept(source *json.RawMessage) (Concept, error) {
 	return ConvertToSimpleConcept(esConcept), nil
 }
 
-func (s *esConceptSearchService) SearchConceptByTextAndTypes(textQuery string, conceptTypes []string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error) {
+func (s *esConceptSearchService) SearchConceptByTextAndTypes(ctx context.Context, textQuery string, conceptTypes []string, opts SearchOptions) (SearchResult, error) {
 	if textQuery == "" {
-		return nil, errEmptyTextParameter
+		return SearchResult{}, errEmptyTextParameter
 	}
 
 	if len(conceptTypes) == 0 {
-		return nil, util.ErrNoConceptTypeParameter
+		return SearchResult{}, util.ErrNoConceptTypeParameter
 	}
-	if err := s.checkElasticClient(); err != nil {
-		return nil, err
-	}
-	return s.searchConceptsForMultipleTypes(textQuery, conceptTypes, "", searchAllAuthorities, includeDeprecated)
+	return s.searchConceptsForMultipleTypes(ctx, textQuery, conceptTypes, "", opts)
 }
 
-func (s *esConceptSearchService) SearchConceptByTextAndTypesWithBoost(textQuery string, conceptTypes []string, boostType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error) {
+func (s *esConceptSearchService) SearchConceptByTextAndTypesWithBoost(ctx context.Context, textQuery string, conceptTypes []string, boostType string, opts SearchOptions) (SearchResult, error) {
 	if err := util.ValidateForAuthorsSearch(conceptTypes, boostType); err != nil {
-		return nil, err
+		return SearchResult{}, err
 	}
 	if textQuery == "" {
-		return nil, errEmptyTextParameter
+		return SearchResult{}, errEmptyTextParameter
 	}
 	if len(conceptTypes) == 0 {
-		return nil, util.ErrNoConceptTypeParameter
+		return SearchResult{}, util.ErrNoConceptTypeParameter
 	}
-	if err := s.checkElasticClient(); err != nil {
-		return nil, err
-	}
-	return s.searchConceptsForMultipleTypes(textQuery, conceptTypes, boostType, searchAllAuthorities, includeDeprecated)
+	return s.searchConceptsForMultipleTypes(ctx, textQuery, conceptTypes, boostType, opts)
 }
 
-func (s *esConceptSearchService) searchConceptsForMultipleTypes(textQuery string, conceptTypes []string, boostType string, searchAllAuthorities bool, includeDeprecated bool) ([]Concept, error) {
-	esTypes, isPublicCompanyType, err := util.ValidateAndConvertToEsTypes(conceptTypes)
+func (s *esConceptSearchService) searchConceptsForMultipleTypes(ctx context.Context, textQuery string, conceptTypes []string, boostType string, opts SearchOptions) (SearchResult, error) {
+	typeFilter, err := ConceptTypesFilter(conceptTypes)
 	if err != nil {
-		return nil, err
+		return SearchResult{}, err
+	}
+	fuzziness, err := util.ResolveFuzziness(false, opts.Fuzziness)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	ranking, profile, err := s.ranking.Get(opts.Profile)
+	if err != nil {
+		return SearchResult{}, err
 	}
 
 	textMatch := elastic.NewMatchQuery("prefLabel.edge_ngram", textQuery)
-	aliasesExactMatchMustQuery := elastic.NewMatchQuery("aliases.edge_ngram", textQuery).Boost(0.8)
-	mustQuery := elastic.NewBoolQuery().Should(textMatch, aliasesExactMatchMustQuery).MinimumNumberShouldMatch(1) // All searches must either match loosely on `prefLabel`, or exactly on `aliases`
-
-	termMatchQuery := elastic.NewMatchQuery("prefLabel", textQuery).Boost(0.1)             // Additional boost added if whole terms match, i.e. Donald Trump =returns=> Donald J Trump higher than Donald Trumpy
-	exactMatchQuery := elastic.NewMatchQuery("prefLabel.exact_match", textQuery).Boost(15) // Further boost if the prefLabel matches exactly (barring special characters)
-
-	topicsBoost := elastic.NewTermQuery("_type", "topics").Boost(1.5)
-	locationBoost := elastic.NewTermQuery("_type", "locations").Boost(0.25)
-	peopleBoost := elastic.NewTermQuery("_type", "people").Boost(0.1)
+	aliasesExactMatchMustQuery := elastic.NewMatchQuery("aliases.edge_ngram", textQuery).Boost(ranking.AliasesMatch)
+	mustMatch := []elastic.Query{textMatch, aliasesExactMatchMustQuery}
+	if fuzziness != "" {
+		// Typo tolerant matches on whole terms, boosted well below the other matches so that these still come first
+		// The first character has to match, which keeps the number of fuzzy terms (and false positives) down
+		mustMatch = append(mustMatch,
+			elastic.NewMatchQuery("prefLabel", textQuery).Fuzziness(fuzziness).PrefixLength(1).Boost(ranking.FuzzyPrefLabelMatch),
+			elastic.NewMatchQuery("aliases", textQuery).Fuzziness(fuzziness).PrefixLength(1).Boost(ranking.FuzzyAliasesMatch),
+		)
+	}
+	mustQuery := elastic.NewBoolQuery().Should(mustMatch...).MinimumNumberShouldMatch(1).QueryName("textMatch") // All searches must either match loosely on `prefLabel`, or exactly on `aliases` (or fuzzily on either, when asked to)
+
+	// The scoring clauses are named, so that the explanation of the scores can be broken down by clause
+	termMatchQuery := elastic.NewMatchQuery("prefLabel", textQuery).Boost(ranking.PrefLabelTermMatch).QueryName("termMatch")                // Additional boost added if whole terms match, i.e. Donald Trump =returns=> Donald J Trump higher than Donald Trumpy
+	exactMatchQuery := elastic.NewMatchQuery("prefLabel.exact_match", textQuery).Boost(ranking.PrefLabelExactMatch).QueryName("exactMatch") // Further boost if the prefLabel matches exactly (barring special characters)
+
+	typeBoosts := []elastic.Query{}
+	typeBoostNames := []string{}
+	for _, esType := range sortedTypes(ranking.TypeBoosts) {
+		name := "typeBoost." + esType
+		typeBoosts = append(typeBoosts, elastic.NewTermQuery("_type", esType).Boost(ranking.TypeBoosts[esType]).QueryName(name))
+		typeBoostNames = append(typeBoostNames, name)
+	}
 
 	// ES library does not support building an exists query like; {"exists": {"field":"scopeNote", "boost":1.7}}
 	// Another option to provide the same functionality/boosting is via a bool query.
-	scopeNoteExistBoost := elastic.NewBoolQuery().Must(elastic.NewExistsQuery("scopeNote")).Boost(1.7)
+	scopeNoteExistBoost := elastic.NewBoolQuery().Must(elastic.NewExistsQuery("scopeNote")).Boost(ranking.ScopeNote).QueryName("scopeNote")
 
 	// Phrase match to ensure that documents that contain all the typed terms (in order) are given the full popularity boost
 	// Also ensure that topics are given a boost which is proportional to the popularity boost
@@ -202,49 +257,133 @@ func (s *esConceptSearchService) searchConceptsForMultipleTypes(textQuery string
 			elastic.NewMatchPhraseQuery("prefLabel.edge_ngram", textQuery),
 			elastic.NewMatchPhraseQuery("aliases.edge_ngram", textQuery),
 		).MinimumNumberShouldMatch(1)).
-		AddScoreFunc(elastic.NewWeightFactorFunction(4.5)).
-		Add(elastic.NewTermQuery("_type", "topics"), elastic.NewWeightFactorFunction(4.0)).
+		AddScoreFunc(elastic.NewWeightFactorFunction(ranking.PhraseMatch))
+	for _, esType := range sortedTypes(ranking.PhraseMatchTypeWeights) {
+		phraseMatchQuery = phraseMatchQuery.Add(elastic.NewTermQuery("_type", esType), elastic.NewWeightFactorFunction(ranking.PhraseMatchTypeWeights[esType]))
+	}
+	phraseMatchQuery = phraseMatchQuery.
 		AddScoreFunc(elastic.NewFieldValueFactorFunction().Field("metrics.annotationsCount").Modifier("ln1p").Missing(0)).
 		AddScoreFunc(elastic.NewFieldValueFactorFunction().Field("metrics.prevWeekAnnotationsCount").Modifier("ln2p").Missing(0)).
 		ScoreMode("multiply").
 		BoostMode("replace")
 
-	popularityBoost := elastic.NewFunctionScoreQuery().AddScoreFunc(elastic.NewFieldValueFactorFunction().Field("metrics.annotationsCount").Modifier("ln1p").Missing(0)).Boost(1.5) // smooth the annotations count
-
-	lastWeekPopularityBoost := elastic.NewFunctionScoreQuery().AddScoreFunc(elastic.NewFieldValueFactorFunction().Field("metrics.prevWeekAnnotationsCount").Modifier("ln1p").Missing(0)).Boost(1.5) // smooth the week annotations count
+	popularityBoost := elastic.NewFunctionScoreQuery().AddScoreFunc(elastic.NewFieldValueFactorFunction().Field("metrics.annotationsCount").Modifier("ln1p").Missing(0)).Boost(ranking.Popularity) // smooth the annotations count
 
-	aliasesExactMatchShouldQuery := elastic.NewMatchQuery("aliases.exact_match", textQuery).Boost(0.85) // Also boost if an alias matches exactly, but this should not precede exact matched prefLabels
+	lastWeekPopularityBoost := elastic.NewFunctionScoreQuery().AddScoreFunc(elastic.NewFieldValueFactorFunction().Field("metrics.prevWeekAnnotationsCount").Modifier("ln1p").Missing(0)).Boost(ranking.LastWeekPopularity) // smooth the week annotations count
 
-	typeFilters := []elastic.Query{elastic.NewTermsQuery("_type", util.ToTerms(esTypes)...)}
-	if isPublicCompanyType {
-		typeFilters = append(typeFilters, elastic.NewTermQuery("directType", util.PublicCompany))
-	}
-	typeFilterQuery := elastic.NewBoolQuery().Should(typeFilters...)
+	aliasesExactMatchShouldQuery := elastic.NewMatchQuery("aliases.exact_match", textQuery).Boost(ranking.AliasesExactMatch).QueryName("aliasesExactMatch") // Also boost if an alias matches exactly, but this should not precede exact matched prefLabels
 
-	shouldMatch := []elastic.Query{termMatchQuery, exactMatchQuery, aliasesExactMatchShouldQuery, topicsBoost, locationBoost, peopleBoost, scopeNoteExistBoost, phraseMatchQuery, popularityBoost, lastWeekPopularityBoost}
+	shouldMatch := []elastic.Query{termMatchQuery, exactMatchQuery, aliasesExactMatchShouldQuery}
+	shouldMatch = append(shouldMatch, typeBoosts...)
+	shouldMatch = append(shouldMatch, scopeNoteExistBoost, namedQuery(phraseMatchQuery, "phraseMatch"), namedQuery(popularityBoost, "popularity"), namedQuery(lastWeekPopularityBoost, "lastWeekPopularity"))
+	clauses := append([]string{"textMatch", "termMatch", "exactMatch", "aliasesExactMatch"}, typeBoostNames...)
+	clauses = append(clauses, "scopeNote", "phraseMatch", "popularity", "lastWeekPopularity")
 
 	if boostType != "" {
-		shouldMatch = append(shouldMatch, elastic.NewTermQuery("isFTAuthor", "true").Boost(1.8))
+		shouldMatch = append(shouldMatch, elastic.NewTermQuery("isFTAuthor", "true").Boost(ranking.Authors).QueryName("authors"))
+		clauses = append(clauses, "authors")
 	}
 
 	mustNotMatch := []elastic.Query{}
 	// by default (include_deprecated is false) the deprecated entities are excluded
-	if !includeDeprecated {
+	if !opts.IncludeDeprecated {
 		mustNotMatch = append(mustNotMatch, elastic.NewTermQuery("isDeprecated", true)) // exclude deprecated docs
 	}
 
-	theQuery := elastic.NewBoolQuery().Must(mustQuery).Should(shouldMatch...).MustNot(mustNotMatch...).Filter(typeFilterQuery).MinimumNumberShouldMatch(0).Boost(1)
+	filters := []elastic.Query{typeFilter}
+	if len(opts.Authorities) > 0 {
+		filters = append(filters, authoritiesFilter(opts.Authorities))
+	}
 
-	index := s.getIndexForAuthoritiesParam(searchAllAuthorities)
-	search := s.esClient.Search(index).Size(s.maxAutoCompleteResults).Query(theQuery)
+	theQuery := elastic.NewBoolQuery().Must(mustQuery).Should(shouldMatch...).MustNot(mustNotMatch...).Filter(filters...).MinimumNumberShouldMatch(0).Boost(1)
 
-	result, err := search.SearchType("dfs_query_then_fetch").Do(context.Background())
+	index := s.getIndexForAuthoritiesParam(opts.SearchAllAuthorities)
+	source := fetchFields(elastic.NewSearchSource().Size(s.maxAutoCompleteResults).Query(theQuery), opts.Fields)
+	if opts.IncludeFacets {
+		source = addFacetAggregations(source)
+	}
+	if opts.Highlight {
+		source = source.Highlight(NewHighlight())
+	}
+	if opts.Explain {
+		source = source.Explain(true)
+	}
+
+	result, err := s.repository.Search(ctx, SearchQuery{Index: index, SearchType: "dfs_query_then_fetch", Source: source})
 	if err != nil {
 		log.Errorf("error: %v", err)
+		return SearchResult{}, err
+	}
+	searchResult := newSearchResult(result, index, s.maxAutoCompleteResults, returnedFields(opts.Fields, opts.IncludeMetrics))
+	searchResult.Profile = profile
+	if opts.Explain {
+		addExplanations(searchResult.Concepts, result, clauses)
+	}
+	if opts.IncludeFacets {
+		searchResult.Facets = FacetsFromResult(result)
+	}
+	return searchResult, nil
+}
+
+// namedQuery names a query which cannot be named otherwise, e.g. a function_score one, by wrapping it in a bool query
+// which scores the same
+func namedQuery(query elastic.Query, name string) elastic.Query {
+	return elastic.NewBoolQuery().Must(query).QueryName(name)
+}
+
+// addListFilters restricts a listing query according to the given options
+func addListFilters(boolQuery *elastic.BoolQuery, opts ListOptions) *elastic.BoolQuery {
+	if !opts.IncludeDeprecated {
+		boolQuery.MustNot(elastic.NewTermQuery("isDeprecated", true))
+	}
+	if len(opts.Authorities) > 0 {
+		boolQuery.Filter(authoritiesFilter(opts.Authorities))
+	}
+	if !opts.ModifiedSince.IsZero() {
+		boolQuery.Filter(modifiedSinceFilter(opts.ModifiedSince))
+	}
+	return boolQuery
+}
+
+// addSubtypeFilter restricts a query on the ES type of the filter to the concepts of its subtype, if any
+func addSubtypeFilter(boolQuery *elastic.BoolQuery, filter util.ConceptTypeFilter) *elastic.BoolQuery {
+	if filter.Subtype != "" {
+		boolQuery.Filter(elastic.NewTermQuery("types", filter.Subtype))
+	}
+	return boolQuery
+}
+
+// ConceptTypesFilter matches the concepts of any of the given types, subtypes included.
+// It fails with an InputError for the types which are not part of the ontology.
+func ConceptTypesFilter(conceptTypes []string) (elastic.Query, error) {
+	filters, err := util.NewConceptTypeFilters(conceptTypes)
+	if err != nil {
 		return nil, err
 	}
-	concepts := searchResultToConcepts(result)
-	return concepts, nil
+
+	esTypes := []string{}
+	typeFilters := []elastic.Query{}
+	for _, filter := range filters {
+		if filter.Subtype == "" {
+			esTypes = append(esTypes, filter.EsType)
+			continue
+		}
+		typeFilters = append(typeFilters, addSubtypeFilter(elastic.NewBoolQuery().Filter(elastic.NewTermQuery("_type", filter.EsType)), filter))
+	}
+	if len(esTypes) > 0 {
+		typeFilters = append(typeFilters, elastic.NewTermsQuery("_type", util.ToTerms(esTypes)...))
+	}
+	return elastic.NewBoolQuery().Should(typeFilters...), nil
+}
+
+// authoritiesFilter matches the concepts which have been sourced from any of the given authorities
+func authoritiesFilter(authorities []string) elastic.Query {
+	return elastic.NewTermsQuery("authorities", util.ToTerms(authorities)...)
+}
+
+// modifiedSinceFilter matches the concepts which have been modified at or after the given time
+func modifiedSinceFilter(since time.Time) elastic.Query {
+	return elastic.NewRangeQuery("lastModified").Gte(since.Format(time.RFC3339Nano))
 }
 
 func containsOnlyEmptyValues(ids []string) bool {
@@ -257,15 +396,7 @@ func containsOnlyEmptyValues(ids []string) bool {
 }
 
 func (s *esConceptSearchService) SetElasticClient(client *elastic.Client) {
-	s.clientLock.Lock()
-	defer s.clientLock.Unlock()
-	s.esClient = client
-}
-
-func (s *esConceptSearchService) elasticClient() *elastic.Client {
-	s.clientLock.RLock()
-	defer s.clientLock.RUnlock()
-	return s.esClient
+	s.repository.SetElasticClient(client)
 }
 
 func (s *esConceptSearchService) getIndexForAuthoritiesParam(searchAllAuthorities bool) string {
@@ -2,11 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Financial-Times/concept-search-api/util"
 	uuid "github.com/satori/go.uuid"
@@ -27,6 +29,8 @@ const (
 	esLocationType         = "locations"
 	esTopicType            = "topics"
 	esAlphavilleSeriesType = "alphaville-series"
+	esSectionType          = "sections"
+	esSubjectType          = "subjects"
 	ftGenreType            = "http://www.ft.com/ontology/Genre"
 	ftBrandType            = "http://www.ft.com/ontology/product/Brand"
 	ftPeopleType           = "http://www.ft.com/ontology/person/Person"
@@ -34,17 +38,25 @@ const (
 	ftLocationType         = "http://www.ft.com/ontology/Location"
 	ftTopicType            = "http://www.ft.com/ontology/Topic"
 	ftAlphavilleSeriesType = "http://www.ft.com/ontology/AlphavilleSeries"
+	ftSectionType          = "http://www.ft.com/ontology/Section"
+	ftSubjectType          = "http://www.ft.com/ontology/Subject"
 	ftPublicCompanies      = "http://www.ft.com/ontology/company/PublicCompany"
 	testMappingFile        = "test/mapping.json"
 )
 
 func TestNoElasticClient(t *testing.T) {
-	service := NewEsConceptSearchService("test", "", 50, 10, 2)
+	service := NewEsConceptSearchService("test", "", 50, 10)
 
-	_, err := service.FindAllConceptsByType(ftGenreType, false, true)
+	_, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true})
 	assert.EqualError(t, err, util.ErrNoElasticClient.Error(), "error response")
 
-	_, err = service.SearchConceptByTextAndTypes("lucy", []string{ftBrandType}, false, true)
+	_, err = service.SearchConceptByTextAndTypes(context.Background(), "lucy", []string{ftBrandType}, SearchOptions{IncludeDeprecated: true})
+	assert.EqualError(t, err, util.ErrNoElasticClient.Error(), "error response")
+
+	_, err = service.FindConceptByUUID(context.Background(), "61d707b5-6fab-3541-b017-49b72de80772", false)
+	assert.EqualError(t, err, util.ErrNoElasticClient.Error(), "error response")
+
+	err = service.ExportConceptsByType(context.Background(), ftGenreType, ListOptions{}, func(c EsConceptModel) error { return nil })
 	assert.EqualError(t, err, util.ErrNoElasticClient.Error(), "error response")
 }
 
@@ -93,6 +105,10 @@ func (s *EsConceptSearchServiceTestSuite) SetupSuite() {
 	require.NoError(s.T(), err, "expected no error in adding topics")
 	err = writeTestConcepts(s.ec, esOrganisationType, ftPublicCompanies, 4)
 	require.NoError(s.T(), err, "expected no error in adding public companies")
+	err = writeTestConcepts(s.ec, esSectionType, ftSectionType, 3)
+	require.NoError(s.T(), err, "expected no error in adding sections")
+	err = writeTestConcepts(s.ec, esSubjectType, ftSubjectType, 2)
+	require.NoError(s.T(), err, "expected no error in adding subjects")
 }
 
 func (s *EsConceptSearchServiceTestSuite) TearDownSuite() {
@@ -306,10 +322,11 @@ func writeTestConceptModel(ec *elastic.Client, esConceptType string, model EsCon
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByType() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.FindAllConceptsByType(ftGenreType, false, true)
+	result, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 
 	assert.NoError(s.T(), err, "expected no error for ES read")
 	assert.Len(s.T(), concepts, 4, "there should be four genres")
@@ -325,9 +342,10 @@ func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByType() {
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeResultSize() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 3, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 3, 10)
 	service.SetElasticClient(s.ec)
-	concepts, err := service.FindAllConceptsByType(ftGenreType, false, true)
+	result, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 
 	assert.NoError(s.T(), err, "expected no error for ES read")
 	assert.Len(s.T(), concepts, 3, "there should be three genres")
@@ -342,17 +360,72 @@ func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeResultSize()
 	}
 }
 
+func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypePaging() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	firstPage, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true, Size: 3})
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Len(s.T(), firstPage.Concepts, 3, "there should be three genres in the first page")
+	assert.NotEmpty(s.T(), firstPage.SearchAfter, "expected a cursor for the next page")
+
+	secondPage, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true, Size: 3, SearchAfter: firstPage.SearchAfter})
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Len(s.T(), secondPage.Concepts, 1, "there should be one genre in the second page")
+	assert.Empty(s.T(), secondPage.SearchAfter, "expected no cursor after the last page")
+
+	assert.Equal(s.T(), -1, strings.Compare(firstPage.Concepts[2].PrefLabel, secondPage.Concepts[0].PrefLabel), "concepts should be ordered across pages")
+
+	fromPage, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true, From: 3, Size: 3})
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Equal(s.T(), secondPage.Concepts, fromPage.Concepts, "from and search_after should return the same page")
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeInvalidPaging() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	_, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{From: -1})
+	assert.EqualError(s.T(), err, errInvalidFromParameter.Error())
+
+	_, err = service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{Size: -1})
+	assert.EqualError(s.T(), err, errInvalidSizeParameter.Error())
+
+	_, err = service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{From: 1, SearchAfter: encodeCursor([]interface{}{"a", "b"})})
+	assert.EqualError(s.T(), err, errFromWithSearchAfter.Error())
+
+	_, err = service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{SearchAfter: "not a cursor"})
+	assert.EqualError(s.T(), err, errInvalidSearchAfterParameter.Error())
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeMetadata() {
+	service := NewEsConceptSearchService(testDefaultIndex, testExtendedIndex, 3, 10)
+	service.SetElasticClient(s.ec)
+
+	result, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true})
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Len(s.T(), result.Concepts, 3, "there should be three genres")
+	assert.Equal(s.T(), int64(4), result.Total, "total hits should include the genres which are not returned")
+	assert.Equal(s.T(), testDefaultIndex, result.Index, "the default index should be used")
+	assert.Equal(s.T(), 3, result.Limit, "the limit should be the search result limit")
+
+	result, err = service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{SearchAllAuthorities: true, Size: 2})
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Equal(s.T(), testExtendedIndex, result.Index, "the extended index should be used")
+	assert.Equal(s.T(), 2, result.Limit, "the limit should be the requested size")
+}
+
 func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeInvalid() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.FindAllConceptsByType("http://www.ft.com/ontology/Foo", false, true)
+	_, err := service.FindAllConceptsByType(context.Background(), "http://www.ft.com/ontology/Foo", ListOptions{IncludeDeprecated: true})
 
 	assert.EqualError(s.T(), err, fmt.Sprintf(util.ErrInvalidConceptTypeFormat, "http://www.ft.com/ontology/Foo"), "expected error")
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeDeprecatedFlag() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid := uuid.NewV4().String()
@@ -371,7 +444,8 @@ func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeDeprecatedFla
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	conceptsWithoutDeprecated, err := service.FindAllConceptsByType("http://www.ft.com/ontology/person/Person", false, false)
+	conceptsWithoutDeprecatedResult, err := service.FindAllConceptsByType(context.Background(), "http://www.ft.com/ontology/person/Person", ListOptions{})
+	conceptsWithoutDeprecated := conceptsWithoutDeprecatedResult.Concepts
 	assert.NoError(s.T(), err, "no error expected")
 
 	for _, concept := range conceptsWithoutDeprecated {
@@ -379,7 +453,8 @@ func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeDeprecatedFla
 		assert.False(s.T(), concept.IsDeprecated)
 	}
 
-	conceptsWithDeprecated, err := service.FindAllConceptsByType("http://www.ft.com/ontology/person/Person", false, true)
+	conceptsWithDeprecatedResult, err := service.FindAllConceptsByType(context.Background(), "http://www.ft.com/ontology/person/Person", ListOptions{IncludeDeprecated: true})
+	conceptsWithDeprecated := conceptsWithDeprecatedResult.Concepts
 	assert.NoError(s.T(), err, "no error expected")
 
 	deprecatedConceptsFound := 0
@@ -396,30 +471,85 @@ func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeDeprecatedFla
 	cleanup(s.T(), s.ec, esPeopleType, uuid)
 }
 
-func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByDirectType() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsBySubtype() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.FindAllConceptsByDirectType(ftPublicCompanies, false, false)
+	result, err := service.FindAllConceptsByType(context.Background(), ftPublicCompanies, ListOptions{})
 
 	assert.NoError(s.T(), err, "expected no error for ES read")
-	assert.Len(s.T(), concepts, 4, "there should be four public companies")
+	assert.Len(s.T(), result.Concepts, 4, "there should be four public companies")
+	for _, c := range result.Concepts {
+		assert.Equal(s.T(), ftPublicCompanies, c.ConceptType, "Results should be of type PublicCompany")
+	}
+}
 
-	var prev string
-	for i := range concepts {
-		if i > 0 {
-			assert.Equal(s.T(), -1, strings.Compare(prev, concepts[i].PrefLabel), "concepts should be ordered")
+func (s *EsConceptSearchServiceTestSuite) TestExportConceptsByType() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 2, 10)
+	service.SetElasticClient(s.ec)
+
+	for _, includeDeprecated := range []bool{true, false} {
+		opts := ListOptions{IncludeDeprecated: includeDeprecated, Size: 2}
+		listed, err := service.FindAllConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: includeDeprecated})
+		require.NoError(s.T(), err)
+
+		var exported []EsConceptModel
+		err = service.ExportConceptsByType(context.Background(), ftGenreType, opts, func(c EsConceptModel) error {
+			exported = append(exported, c)
+			return nil
+		})
+		assert.NoError(s.T(), err, "expected no error for ES scroll")
+		assert.Len(s.T(), exported, len(listed.Concepts), "every genre should be exported, regardless of the result limit")
+		for _, c := range exported {
+			assert.Equal(s.T(), ftGenreType, c.DirectType, "Results should be of type FT Genre")
 		}
-		assert.Equal(s.T(), ftPublicCompanies, concepts[i].ConceptType, "Results should be of type PublicCompany")
-		prev = concepts[i].PrefLabel
 	}
 }
 
+func (s *EsConceptSearchServiceTestSuite) TestExportConceptsByTypePublicCompanies() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	exported := 0
+	err := service.ExportConceptsByType(context.Background(), ftPublicCompanies, ListOptions{}, func(c EsConceptModel) error {
+		assert.Equal(s.T(), ftPublicCompanies, c.DirectType, "Results should be of type PublicCompany")
+		exported++
+		return nil
+	})
+	assert.NoError(s.T(), err, "expected no error for ES scroll")
+	assert.Equal(s.T(), 4, exported, "there should be four public companies")
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestExportConceptsByTypeHandleError() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	handled := 0
+	expectedErr := errors.New("client went away")
+	err := service.ExportConceptsByType(context.Background(), ftGenreType, ListOptions{IncludeDeprecated: true}, func(c EsConceptModel) error {
+		handled++
+		return expectedErr
+	})
+	assert.Equal(s.T(), expectedErr, err, "the export should stop at the first error")
+	assert.Equal(s.T(), 1, handled)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestExportConceptsByTypeInvalidType() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	err := service.ExportConceptsByType(context.Background(), "http://www.ft.com/ontology/Foo", ListOptions{}, func(c EsConceptModel) error {
+		return nil
+	})
+	assert.IsType(s.T(), util.InputError{}, err)
+}
+
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypes() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypes("test", []string{ftPeopleType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftPeopleType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 8)
 
@@ -428,11 +558,24 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypes() {
 	}
 }
 
+func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesMetadata() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 5)
+	service.SetElasticClient(s.ec)
+
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftPeopleType}, SearchOptions{IncludeDeprecated: true})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), result.Concepts, 5)
+	assert.Equal(s.T(), int64(8), result.Total)
+	assert.Equal(s.T(), testDefaultIndex, result.Index)
+	assert.Equal(s.T(), 5, result.Limit)
+}
+
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesMultipleTypes() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypes("test", []string{ftBrandType, ftAlphavilleSeriesType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftBrandType, ftAlphavilleSeriesType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 5)
 
@@ -441,11 +584,64 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesMultipl
 	}
 }
 
+func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithFacets() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 2)
+	service.SetElasticClient(s.ec)
+
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftSectionType, ftSubjectType}, SearchOptions{IncludeDeprecated: true, IncludeFacets: true})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), result.Concepts, 2)
+	assert.ElementsMatch(s.T(), []FacetBucket{{Value: ftSectionType, Count: 3}, {Value: ftSubjectType, Count: 2}}, result.Facets["type"], "the facets should count every matching concept, not only the returned ones")
+	assert.Contains(s.T(), result.Facets, "authorities")
+	assert.Contains(s.T(), result.Facets, "isDeprecated")
+	assert.Contains(s.T(), result.Facets, "isFTAuthor")
+
+	result, err = service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftSectionType, ftSubjectType}, SearchOptions{IncludeDeprecated: true})
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), result.Facets, "no facets should be computed unless requested")
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeSectionsAndSubjects() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	sectionsResult, err := service.FindAllConceptsByType(context.Background(), ftSectionType, ListOptions{IncludeDeprecated: true})
+	sections := sectionsResult.Concepts
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Len(s.T(), sections, 3, "there should be three sections")
+	for _, concept := range sections {
+		assert.Equal(s.T(), ftSectionType, concept.ConceptType, "Results should be of type FT Section")
+	}
+
+	subjectsResult, err := service.FindAllConceptsByType(context.Background(), ftSubjectType, ListOptions{IncludeDeprecated: true})
+	subjects := subjectsResult.Concepts
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Len(s.T(), subjects, 2, "there should be two subjects")
+	for _, concept := range subjects {
+		assert.Equal(s.T(), ftSubjectType, concept.ConceptType, "Results should be of type FT Subject")
+	}
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesSectionsAndSubjects() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftSectionType, ftSubjectType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), concepts, 5)
+
+	for _, concept := range concepts {
+		assert.True(s.T(), concept.ConceptType == ftSectionType || concept.ConceptType == ftSubjectType, "expect concept to be either section or subject")
+	}
+}
+
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesPublicCompanies() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypes("test", []string{ftPublicCompanies}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftPublicCompanies}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 4)
 
@@ -455,10 +651,11 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesPublicC
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesMultipleTypesWithPublicCompanies() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypes("test", []string{ftBrandType, ftPublicCompanies}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "test", []string{ftBrandType, ftPublicCompanies}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 8)
 
@@ -468,10 +665,10 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesMultipl
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesNoText() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.SearchConceptByTextAndTypes("", []string{ftPeopleType}, false, true)
+	_, err := service.SearchConceptByTextAndTypes(context.Background(), "", []string{ftPeopleType}, SearchOptions{IncludeDeprecated: true})
 	assert.EqualError(s.T(), err, errEmptyTextParameter.Error())
 }
 
@@ -482,10 +679,11 @@ func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsSingle() {
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.FindConceptsById([]string{uuid1})
+	result, err := service.FindConceptsById(context.Background(), []string{uuid1})
+	concepts := result.Concepts
 
 	assert.NoError(s.T(), err, "expected no error for ES read")
 	assert.Len(s.T(), concepts, 1, "there should be one concept")
@@ -506,12 +704,13 @@ func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsMultiple() {
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	testIds := []string{uuid1, uuid2}
 
-	concepts, err := service.FindConceptsById(testIds)
+	result, err := service.FindConceptsById(context.Background(), testIds)
+	concepts := result.Concepts
 
 	assert.NoError(s.T(), err, "expected no error for ES read")
 	assert.Len(s.T(), concepts, 2, "there should be two concepts")
@@ -526,89 +725,222 @@ func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsMultiple() {
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsSingleInvalidUUID() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.FindConceptsById([]string{"uuid1"})
+	_, err := service.FindConceptsById(context.Background(), []string{"uuid1"})
 
-	assert.NoError(s.T(), err, "expected no error for ES read")
-	assert.Len(s.T(), concepts, 0, "there should be no concepts")
+	assert.Equal(s.T(), util.NewParameterError(util.ErrCodeInvalidParameter, "ids", "invalid concept ids 'uuid1' (they should be UUIDs or FT concept URIs)"), err, "malformed ids should be rejected")
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsMultipleMixValidInvalid() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	testIds := []string{uuid.NewV4().String(), "xxx", uuid.NewV4().String(), "zzzz"}
+
+	_, err := service.FindConceptsById(context.Background(), testIds)
+
+	assert.Equal(s.T(), util.NewParameterError(util.ErrCodeInvalidParameter, "ids", "invalid concept ids 'xxx', 'zzzz' (they should be UUIDs or FT concept URIs)"), err, "every malformed id should be reported")
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsURIs() {
 	uuid1 := uuid.NewV4().String()
-	err := writeTestConcept(s.ec, uuid1, esOrganisationType, ftOrganisationType, "Betty Phillips", []string{}, nil)
+	err := writeTestConcept(s.ec, uuid1, esPeopleType, ftPeopleType, "Hattie Phillips", []string{}, nil)
 	require.NoError(s.T(), err)
-
 	uuid2 := uuid.NewV4().String()
-	err = writeTestConcept(s.ec, uuid2, esLocationType, ftLocationType, "big pond", []string{}, nil)
+	err = writeTestConcept(s.ec, uuid2, esPeopleType, ftPeopleType, "Harry Phillips", []string{}, nil)
 	require.NoError(s.T(), err)
+	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
+
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	result, err := service.FindConceptsById(context.Background(), []string{"http://www.ft.com/thing/" + uuid1, "http://api.ft.com/people/" + uuid2})
+
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	assert.Len(s.T(), result.Concepts, 2, "the concepts should be found by any of their URIs")
 
+	cleanup(s.T(), s.ec, esPeopleType, uuid1)
+	cleanup(s.T(), s.ec, esPeopleType, uuid2)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestLookupConcepts() {
+	uuid1 := uuid.NewV4().String()
+	err := writeTestConcept(s.ec, uuid1, esOrganisationType, ftOrganisationType, "Harriet Phillips", []string{}, nil)
+	require.NoError(s.T(), err)
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, testExtendedIndex, 1, 10)
 	service.SetElasticClient(s.ec)
 
-	testIds := []string{uuid1, "xxx", uuid2, "zzzz"}
+	unknown := uuid.NewV4().String()
+	result, err := service.LookupConcepts(context.Background(), []string{"http://api.ft.com/things/" + uuid1, unknown})
 
-	concepts, err := service.FindConceptsById(testIds)
+	assert.NoError(s.T(), err, "expected no error for ES read")
+	require.Len(s.T(), result.Concepts, 2, "every looked up id should be in the result")
+	require.NotNil(s.T(), result.Concepts["http://api.ft.com/things/"+uuid1])
+	assert.Equal(s.T(), "Harriet Phillips", result.Concepts["http://api.ft.com/things/"+uuid1].PrefLabel)
+	assert.Nil(s.T(), result.Concepts[unknown], "unknown ids should be mapped to nil")
+	assert.Empty(s.T(), result.FromExtendedIndex)
 
+	cleanup(s.T(), s.ec, esOrganisationType, uuid1)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindConceptByUUID() {
+	uuid1 := uuid.NewV4().String()
+	ftAuthor := "true"
+	model := EsConceptModel{
+		Id:               uuid1,
+		ApiUrl:           fmt.Sprintf("%s/%s/%s", apiBaseURL, esPeopleType, uuid1),
+		PrefLabel:        "Margaret Phillips",
+		Types:            []string{ftPeopleType},
+		DirectType:       ftPeopleType,
+		Aliases:          []string{"Margaret Phillips", "Maggie Phillips"},
+		Authorities:      []string{"TME", "Smartlogic"},
+		IsFTAuthor:       &ftAuthor,
+		Metrics:          &ConceptMetrics{AnnotationsCount: 42, PrevWeekAnnotationsCount: 7},
+		LastModified:     "2019-05-13T10:12:02.123Z",
+		PublishReference: "tid_test_find_by_uuid",
+	}
+	err := writeTestConceptModel(s.ec, esPeopleType, model)
+	require.NoError(s.T(), err)
+	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
+
+	service := NewEsConceptSearchService(testDefaultIndex, testExtendedIndex, 10, 10)
+	service.SetElasticClient(s.ec)
+
+	concept, err := service.FindConceptByUUID(context.Background(), uuid1, false)
 	assert.NoError(s.T(), err, "expected no error for ES read")
-	assert.Len(s.T(), concepts, 2, "there should be two concepts")
-	conceptIds := []string{}
-	for _, concept := range concepts {
-		conceptIds = append(conceptIds, concept.Id)
+	assert.Equal(s.T(), model, concept, "the full concept should be returned")
+
+	_, err = service.FindConceptByUUID(context.Background(), uuid1, true)
+	assert.EqualError(s.T(), err, util.ErrConceptNotFound.Error(), "the concept is not in the extended index")
+
+	cleanup(s.T(), s.ec, esPeopleType, uuid1)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFilterByAuthorities() {
+	factsetUUID := uuid.NewV4().String()
+	tmeUUID := uuid.NewV4().String()
+	for id, authority := range map[string]string{factsetUUID: "FACTSET", tmeUUID: "TME"} {
+		err := writeTestConceptModel(s.ec, esOrganisationType, EsConceptModel{
+			Id:          id,
+			ApiUrl:      fmt.Sprintf("%s/%s/%s", apiBaseURL, esOrganisationType, id),
+			PrefLabel:   "Authoritative Holdings " + authority,
+			Types:       []string{ftOrganisationType},
+			DirectType:  ftOrganisationType,
+			Aliases:     []string{"Authoritative Holdings " + authority},
+			Authorities: []string{authority},
+		})
+		require.NoError(s.T(), err)
 	}
-	assert.Contains(s.T(), conceptIds, uuid1, "retrieved concepts should contain id %s ", uuid1)
-	assert.Contains(s.T(), conceptIds, uuid2, "retrieved concepts should contain id %s ", uuid2)
+	_, err := s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
 
-	cleanup(s.T(), s.ec, esOrganisationType, uuid1)
-	cleanup(s.T(), s.ec, esLocationType, uuid2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	listResult, err := service.FindAllConceptsByType(context.Background(), ftOrganisationType, ListOptions{Authorities: []string{"FACTSET"}})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), listResult.Concepts, 1, "only the FactSet organisation should be listed")
+	assert.Equal(s.T(), "Authoritative Holdings FACTSET", listResult.Concepts[0].PrefLabel)
+
+	searchResult, err := service.SearchConceptByTextAndTypes(context.Background(), "Authoritative Holdings", []string{ftOrganisationType}, SearchOptions{Authorities: []string{"TME"}})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), searchResult.Concepts, 1, "only the TME organisation should be found")
+	assert.Equal(s.T(), "Authoritative Holdings TME", searchResult.Concepts[0].PrefLabel)
+
+	searchResult, err = service.SearchConceptByTextAndTypes(context.Background(), "Authoritative Holdings", []string{ftOrganisationType}, SearchOptions{Authorities: []string{"TME", "FACTSET"}})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), searchResult.Concepts, 2, "concepts from any of the given authorities should be found")
+
+	cleanup(s.T(), s.ec, esOrganisationType, factsetUUID, tmeUUID)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindAllConceptsByTypeModifiedSince() {
+	oldUUID := uuid.NewV4().String()
+	newUUID := uuid.NewV4().String()
+	for id, lastModified := range map[string]string{oldUUID: "2019-01-01T00:00:00Z", newUUID: "2019-06-01T10:12:02.123Z"} {
+		err := writeTestConceptModel(s.ec, esOrganisationType, EsConceptModel{
+			Id:               id,
+			ApiUrl:           fmt.Sprintf("%s/%s/%s", apiBaseURL, esOrganisationType, id),
+			PrefLabel:        "Modified Holdings " + lastModified,
+			Types:            []string{ftOrganisationType},
+			DirectType:       ftOrganisationType,
+			LastModified:     lastModified,
+			PublishReference: "tid_" + id,
+		})
+		require.NoError(s.T(), err)
+	}
+	_, err := s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
+
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	result, err := service.FindAllConceptsByType(context.Background(), ftOrganisationType, ListOptions{ModifiedSince: time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), result.Concepts, 1, "only the concept modified since March should be listed")
+	assert.Equal(s.T(), "2019-06-01T10:12:02.123Z", result.Concepts[0].LastModified)
+	assert.Equal(s.T(), "tid_"+newUUID, result.Concepts[0].PublishReference)
+
+	cleanup(s.T(), s.ec, esOrganisationType, oldUUID, newUUID)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestFindConceptByUUIDNotFound() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	_, err := service.FindConceptByUUID(context.Background(), uuid.NewV4().String(), false)
+	assert.EqualError(s.T(), err, util.ErrConceptNotFound.Error())
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsEmptyStringValue() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.FindConceptsById([]string{""})
+	_, err := service.FindConceptsById(context.Background(), []string{""})
 	assert.EqualError(s.T(), err, errEmptyIdsParameter.Error())
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsEmptySlice() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.FindConceptsById([]string{})
+	_, err := service.FindConceptsById(context.Background(), []string{})
 	assert.EqualError(s.T(), err, errEmptyIdsParameter.Error())
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindConceptsByIdsNilSlice() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.FindConceptsById(nil)
+	_, err := service.FindConceptsById(context.Background(), nil)
 	assert.EqualError(s.T(), err, errEmptyIdsParameter.Error())
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesNoConceptTypes() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.SearchConceptByTextAndTypes("pippo", []string{}, false, true)
+	_, err := service.SearchConceptByTextAndTypes(context.Background(), "pippo", []string{}, SearchOptions{IncludeDeprecated: true})
 	assert.EqualError(s.T(), err, util.ErrNoConceptTypeParameter.Error())
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesInvalidConceptType() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	_, err := service.SearchConceptByTextAndTypes("pippo", []string{"http://www.ft.com/ontology/Foo"}, false, true)
+	_, err := service.SearchConceptByTextAndTypes(context.Background(), "pippo", []string{"http://www.ft.com/ontology/Foo"}, SearchOptions{IncludeDeprecated: true})
 	assert.EqualError(s.T(), err, fmt.Sprintf(util.ErrInvalidConceptTypeFormat, "http://www.ft.com/ontology/Foo"))
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesTermMatchBoosted() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -622,7 +954,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesTermMat
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("donald trump", []string{ftPeopleType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "donald trump", []string{ftPeopleType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 2)
 
@@ -634,8 +967,109 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesTermMat
 	cleanup(s.T(), s.ec, esPeopleType, uuid1, uuid2)
 }
 
+func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesFuzzy() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	uuid1 := uuid.NewV4().String()
+	err := writeTestConcept(s.ec, uuid1, esPeopleType, ftPeopleType, "Donaldo Trump", []string{}, nil)
+	require.NoError(s.T(), err)
+
+	uuid2 := uuid.NewV4().String()
+	err = writeTestConcept(s.ec, uuid2, esPeopleType, ftPeopleType, "Donald J Trump", []string{"Donald Trump"}, nil)
+	require.NoError(s.T(), err)
+
+	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
+
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "trunp", []string{ftPeopleType}, SearchOptions{})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), result.Concepts, "a typo should not match unless fuzzy matching is requested")
+
+	for _, fuzziness := range []string{"AUTO", "1", "2"} {
+		result, err = service.SearchConceptByTextAndTypes(context.Background(), "trunp", []string{ftPeopleType}, SearchOptions{Fuzziness: fuzziness})
+		assert.NoError(s.T(), err)
+		assert.Len(s.T(), result.Concepts, 2, "a typo should match with fuzziness %v", fuzziness)
+	}
+
+	exactResult, err := service.SearchConceptByTextAndTypes(context.Background(), "donald trump", []string{ftPeopleType}, SearchOptions{})
+	require.NoError(s.T(), err)
+	fuzzyResult, err := service.SearchConceptByTextAndTypes(context.Background(), "donald trump", []string{ftPeopleType}, SearchOptions{Fuzziness: "AUTO"})
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), fuzzyResult.Concepts, len(exactResult.Concepts))
+	assert.Equal(s.T(), "Donald J Trump", fuzzyResult.Concepts[0].PrefLabel, "the exact match should still come first")
+	for i := range exactResult.Concepts {
+		assert.Equal(s.T(), exactResult.Concepts[i].Id, fuzzyResult.Concepts[i].Id, "fuzzy matching should not change the ranking of exact matches")
+	}
+
+	_, err = service.SearchConceptByTextAndTypes(context.Background(), "donald trump", []string{ftPeopleType}, SearchOptions{Fuzziness: "3"})
+	assert.EqualError(s.T(), err, util.ErrInvalidFuzzinessParameter.Error())
+
+	cleanup(s.T(), s.ec, esPeopleType, uuid1, uuid2)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesHighlight() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	uuid1 := uuid.NewV4().String()
+	err := writeTestConcept(s.ec, uuid1, esPeopleType, ftPeopleType, "Bartholomew Quillfeather", []string{"Bartholomew Quillfeather", "Barty Q"}, nil)
+	require.NoError(s.T(), err)
+
+	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
+
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "barty", []string{ftPeopleType}, SearchOptions{Highlight: true})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), result.Concepts, 1)
+	assert.Equal(s.T(), Highlight{"aliases": {"<em>Barty</em> Q"}}, result.Concepts[0].Highlight, "the concept should be highlighted as matching on an alias only")
+
+	result, err = service.SearchConceptByTextAndTypes(context.Background(), "quill", []string{ftPeopleType}, SearchOptions{Highlight: true})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), result.Concepts, 1)
+	assert.Equal(s.T(), []string{"Bartholomew <em>Quillfeather</em>"}, result.Concepts[0].Highlight["prefLabel"], "the concept should be highlighted as matching on the prefLabel")
+
+	result, err = service.SearchConceptByTextAndTypes(context.Background(), "quill", []string{ftPeopleType}, SearchOptions{})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), result.Concepts, 1)
+	assert.Nil(s.T(), result.Concepts[0].Highlight, "no highlight should be returned unless requested")
+
+	cleanup(s.T(), s.ec, esPeopleType, uuid1)
+}
+
+func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExplain() {
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
+	service.SetElasticClient(s.ec)
+
+	uuid1 := uuid.NewV4().String()
+	err := writeTestConcept(s.ec, uuid1, esPeopleType, ftPeopleType, "Bartholomew Quillfeather", []string{"Bartholomew Quillfeather", "Barty Q"}, nil)
+	require.NoError(s.T(), err)
+
+	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
+	require.NoError(s.T(), err)
+
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "bartholomew quillfeather", []string{ftPeopleType}, SearchOptions{Explain: true})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), result.Concepts, 1)
+	explanation := result.Concepts[0].Explanation
+	require.NotNil(s.T(), explanation)
+	assert.NotNil(s.T(), explanation.Details)
+	assert.Contains(s.T(), explanation.Contributions, "textMatch")
+	assert.Contains(s.T(), explanation.Contributions, "exactMatch")
+	assert.Contains(s.T(), explanation.Contributions, "phraseMatch")
+	assert.True(s.T(), explanation.Contributions["exactMatch"] > explanation.Contributions["textMatch"], "the exact match should contribute the most")
+
+	result, err = service.SearchConceptByTextAndTypes(context.Background(), "bartholomew quillfeather", []string{ftPeopleType}, SearchOptions{})
+	assert.NoError(s.T(), err)
+	require.Len(s.T(), result.Concepts, 1)
+	assert.Nil(s.T(), result.Concepts[0].Explanation, "no explanation should be returned unless requested")
+
+	cleanup(s.T(), s.ec, esPeopleType, uuid1)
+}
+
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExactMatchBoosted() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -649,7 +1083,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExactMa
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("new york", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "new york", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 2)
 
@@ -662,7 +1097,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExactMa
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExactMatchBoostedWithScopeNotePresent() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -676,7 +1111,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExactMa
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("new yor", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "new yor", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 2)
 
@@ -689,7 +1125,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesExactMa
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesDeprecated() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -711,7 +1147,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesDepreca
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("new york", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "new york", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 2)
 
@@ -721,7 +1158,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesDepreca
 	assert.Equal(s.T(), "New York", nyc.PrefLabel, "Failure could indicate that the wrong concept had the higher boost")
 	assert.Equal(s.T(), "New York Deprecated", nycDeprecated.PrefLabel, "Failure could indicate that the wrong concept had the higher boost")
 
-	concepts, err = service.SearchConceptByTextAndTypes("new york", []string{ftLocationType}, false, false)
+	result, err = service.SearchConceptByTextAndTypes(context.Background(), "new york", []string{ftLocationType}, SearchOptions{})
+	concepts = result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 1)
 
@@ -733,7 +1171,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesDepreca
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAuthorsBoost() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -751,7 +1189,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAut
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("robert shrimpley", []string{ftPeopleType}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "robert shrimpley", []string{ftPeopleType}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 3)
 
@@ -767,7 +1206,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAut
 
 // If 4 concepts are equivalent, then the type boosts should order them as expected.
 func (s *EsConceptSearchServiceTestSuite) TestSearch__SpecificTypesAreBoosted() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -789,7 +1228,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearch__SpecificTypesAreBoosted()
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("Fannie Mae", []string{ftPeopleType, ftTopicType, ftLocationType, ftOrganisationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "Fannie Mae", []string{ftPeopleType, ftTopicType, ftLocationType, ftOrganisationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), concepts, 4)
 
@@ -810,7 +1250,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearch__SpecificTypesAreBoosted()
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAuthorsBoostAndDeprecated() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -842,7 +1282,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAut
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	conceptsWithDeprecated, err := service.SearchConceptByTextAndTypesWithBoost("robert shrimple", []string{ftPeopleType}, "authors", false, true)
+	conceptsWithDeprecatedResult, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "robert shrimple", []string{ftPeopleType}, "authors", SearchOptions{IncludeDeprecated: true})
+	conceptsWithDeprecated := conceptsWithDeprecatedResult.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), conceptsWithDeprecated, 4)
 
@@ -856,7 +1297,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAut
 	assert.Equal(s.T(), "Robert Real Shrimpley", theRealEditor.PrefLabel)
 	assert.Equal(s.T(), "Roberto Shrimpley", theFake.PrefLabel)
 
-	conceptsWithoutDeprecated, err := service.SearchConceptByTextAndTypesWithBoost("robert shrimpley", []string{ftPeopleType}, "authors", false, false)
+	conceptsWithoutDeprecatedResult, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "robert shrimpley", []string{ftPeopleType}, "authors", SearchOptions{})
+	conceptsWithoutDeprecated := conceptsWithoutDeprecatedResult.Concepts
 	assert.NoError(s.T(), err)
 	assert.Len(s.T(), conceptsWithoutDeprecated, 3)
 
@@ -872,7 +1314,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithAut
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByExactMatchAliases() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -886,7 +1328,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByExactMatchAliases(
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("USA", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "USA", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 2)
 
@@ -899,68 +1342,75 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByExactMatchAliases(
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithBoostRestrictedSize() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 1, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 1)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("test", []string{ftPeopleType}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftPeopleType}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.NoError(s.T(), err, "expected no error for ES read")
 	assert.Len(s.T(), concepts, 1, "there should be one results")
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithBoostNoInputText() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("", []string{ftPeopleType}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "", []string{ftPeopleType}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.EqualError(s.T(), err, errEmptyTextParameter.Error())
 	assert.Nil(s.T(), concepts)
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithBoostNoTypes() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("test", []string{}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.EqualError(s.T(), err, util.ErrNoConceptTypeParameter.Error())
 	assert.Nil(s.T(), concepts)
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithBoostMultipleTypes() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("test", []string{ftPeopleType, ftLocationType}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftPeopleType, ftLocationType}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.EqualError(s.T(), err, util.ErrNotSupportedCombinationOfConceptTypes.Error())
 	assert.Nil(s.T(), concepts)
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithInvalidBoost() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("test", []string{ftPeopleType}, "pluto", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftPeopleType}, "pluto", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.EqualError(s.T(), err, util.ErrInvalidBoostTypeParameter.Error())
 	assert.Nil(s.T(), concepts)
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithBoostNoESConnection() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("test", []string{ftPeopleType}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftPeopleType}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.EqualError(s.T(), err, util.ErrNoElasticClient.Error())
 	assert.Nil(s.T(), concepts)
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptByTextAndTypesWithBoostInvalidConceptType() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 
-	concepts, err := service.SearchConceptByTextAndTypesWithBoost("test", []string{ftGenreType}, "authors", false, true)
+	result, err := service.SearchConceptByTextAndTypesWithBoost(context.Background(), "test", []string{ftGenreType}, "authors", SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	assert.EqualError(s.T(), err, fmt.Sprintf(util.ErrInvalidConceptTypeFormat, ftGenreType))
 	assert.Nil(s.T(), concepts)
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByPopularity() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -974,7 +1424,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByPopularity() {
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("USA", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "USA", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 2)
 
@@ -987,7 +1438,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByPopularity() {
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByPopularityAliasMatch() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -1001,7 +1452,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByPopularityAliasMat
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("Dr G", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "Dr G", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 2)
 
@@ -1014,7 +1466,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByPopularityAliasMat
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularitySameAnnotationsCount() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -1028,7 +1480,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularitySa
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("USA", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "USA", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 2)
 
@@ -1041,7 +1494,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularitySa
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularityNoRecentAnnotations() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -1055,7 +1508,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularityNo
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("USA", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "USA", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 2)
 
@@ -1068,7 +1522,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularityNo
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularity() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -1082,7 +1536,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularity()
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("USA", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "USA", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 2)
 
@@ -1095,7 +1550,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByRecentPopularity()
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByAliasPartialMatch() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid1 := uuid.NewV4().String()
@@ -1109,7 +1564,8 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByAliasPartialMatch(
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("roose", []string{ftLocationType}, false, true)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "roose", []string{ftLocationType}, SearchOptions{IncludeDeprecated: true})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 1)
 
@@ -1120,7 +1576,7 @@ func (s *EsConceptSearchServiceTestSuite) TestSearchConceptsByAliasPartialMatch(
 }
 
 func (s *EsConceptSearchServiceTestSuite) TestFindOrganisationWithCountryCodeAndCountryOfIncorporation() {
-	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10, 2)
+	service := NewEsConceptSearchService(testDefaultIndex, "", 10, 10)
 	service.SetElasticClient(s.ec)
 
 	uuid := uuid.NewV4().String()
@@ -1130,7 +1586,8 @@ func (s *EsConceptSearchServiceTestSuite) TestFindOrganisationWithCountryCodeAnd
 	_, err = s.ec.Refresh(testDefaultIndex).Do(context.Background())
 	require.NoError(s.T(), err)
 
-	concepts, err := service.SearchConceptByTextAndTypes("Moo", []string{ftOrganisationType}, false, false)
+	result, err := service.SearchConceptByTextAndTypes(context.Background(), "Moo", []string{ftOrganisationType}, SearchOptions{})
+	concepts := result.Concepts
 	require.NoError(s.T(), err)
 	require.Len(s.T(), concepts, 1)
 
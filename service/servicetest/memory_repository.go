@@ -0,0 +1,344 @@
+// Package servicetest provides fakes for testing code built on the service package without an Elasticsearch cluster.
+package servicetest
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const defaultSearchSize = 10 // as in Elasticsearch
+
+type memoryConcept struct {
+	esType  string
+	id      string
+	concept service.EsConceptModel
+}
+
+// InMemoryConceptRepository is a service.ConceptRepository keeping its concepts in memory, which stands in for the
+// Elasticsearch cluster in tests. It does not evaluate the queries, apart from ids ones: every concept of the
+// requested index and types is a hit, sorted by prefLabel and id, and paged according to from, size and search_after.
+// The queries it receives are recorded, so that tests can check what would have been sent to the cluster.
+type InMemoryConceptRepository struct {
+	lock     *sync.RWMutex
+	concepts map[string][]memoryConcept
+	queries  []service.SearchQuery
+	err      error
+}
+
+// NewInMemoryConceptRepository returns an empty in-memory repository.
+func NewInMemoryConceptRepository() *InMemoryConceptRepository {
+	return &InMemoryConceptRepository{
+		lock:     &sync.RWMutex{},
+		concepts: make(map[string][]memoryConcept),
+	}
+}
+
+// Add stores the given concepts in the index, under the given ES type. Their ES id is the last segment of their id.
+func (r *InMemoryConceptRepository) Add(index string, esType string, concepts ...service.EsConceptModel) *InMemoryConceptRepository {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, c := range concepts {
+		r.concepts[index] = append(r.concepts[index], memoryConcept{esType: esType, id: path.Base(c.Id), concept: c})
+	}
+	return r
+}
+
+// FailWith makes every subsequent call to the repository fail with the given error.
+func (r *InMemoryConceptRepository) FailWith(err error) *InMemoryConceptRepository {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.err = err
+	return r
+}
+
+// Queries returns the queries received by Search and Scroll so far.
+func (r *InMemoryConceptRepository) Queries() []service.SearchQuery {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]service.SearchQuery{}, r.queries...)
+}
+
+// SetElasticClient is a no-op, the in-memory repository does not need a client.
+func (r *InMemoryConceptRepository) SetElasticClient(client *elastic.Client) {}
+
+func (r *InMemoryConceptRepository) Search(ctx context.Context, query service.SearchQuery) (*elastic.SearchResult, error) {
+	if err := r.record(ctx, query); err != nil {
+		return nil, err
+	}
+	return r.search(query.Index, query.Types, query.Source)
+}
+
+func (r *InMemoryConceptRepository) MultiSearch(ctx context.Context, index string, requests ...*elastic.SearchRequest) (*elastic.MultiSearchResult, error) {
+	if err := r.check(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &elastic.MultiSearchResult{}
+	for _, request := range requests {
+		body, err := request.Body()
+		if err != nil {
+			return nil, err
+		}
+		response, err := r.search(index, nil, json.RawMessage(body))
+		if err != nil {
+			return nil, err
+		}
+		result.Responses = append(result.Responses, response)
+	}
+	return result, nil
+}
+
+func (r *InMemoryConceptRepository) Scroll(ctx context.Context, query service.SearchQuery, handle func(*elastic.SearchResult) error) error {
+	if err := r.record(ctx, query); err != nil {
+		return err
+	}
+
+	params, err := readSearchParams(query.Source)
+	if err != nil {
+		return err
+	}
+	batchSize := params.size()
+	if batchSize <= 0 {
+		batchSize = defaultSearchSize
+	}
+	hits := r.hits(query.Index, query.Types, params.ids(), false)
+	for from := 0; from < len(hits); from += batchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		to := from + batchSize
+		if to > len(hits) {
+			to = len(hits)
+		}
+		if err := handle(newMemorySearchResult(hits[from:to], len(hits))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryConceptRepository) MultiGet(ctx context.Context, index string, ids []string) (*elastic.MgetResponse, error) {
+	if err := r.check(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &elastic.MgetResponse{}
+	for _, id := range ids {
+		doc := &elastic.GetResult{Index: index, Id: id}
+		if hits := r.hits(index, nil, []string{id}, false); len(hits) > 0 {
+			doc.Type = hits[0].Type
+			doc.Source = hits[0].Source
+			doc.Found = true
+		}
+		result.Docs = append(result.Docs, doc)
+	}
+	return result, nil
+}
+
+// ClusterHealth reports a green cluster, unless the repository has been told to fail.
+func (r *InMemoryConceptRepository) ClusterHealth(ctx context.Context) (*elastic.ClusterHealthResponse, error) {
+	if err := r.check(ctx); err != nil {
+		return nil, err
+	}
+	return &elastic.ClusterHealthResponse{Status: "green"}, nil
+}
+
+func (r *InMemoryConceptRepository) check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.err
+}
+
+func (r *InMemoryConceptRepository) record(ctx context.Context, query service.SearchQuery) error {
+	if err := r.check(ctx); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.queries = append(r.queries, query)
+	return nil
+}
+
+func (r *InMemoryConceptRepository) search(index string, types []string, source interface{}) (*elastic.SearchResult, error) {
+	params, err := readSearchParams(source)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := r.hits(index, types, params.ids(), params.byPopularity())
+	total := len(hits)
+	if len(params.SearchAfter) > 0 {
+		hits = hitsAfter(hits, params.SearchAfter)
+	} else if params.From != nil {
+		if *params.From >= len(hits) {
+			hits = nil
+		} else {
+			hits = hits[*params.From:]
+		}
+	}
+	if len(hits) > params.size() {
+		hits = hits[:params.size()]
+	}
+	return newMemorySearchResult(hits, total), nil
+}
+
+// hits returns the concepts of the index matching the types and ids (when given), sorted by prefLabel and id, after the
+// most annotated ones when sorted by popularity
+func (r *InMemoryConceptRepository) hits(index string, types []string, ids []string, byPopularity bool) []*elastic.SearchHit {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	hits := []*elastic.SearchHit{}
+	for _, c := range r.concepts[index] {
+		if len(types) > 0 && !contains(types, c.esType) {
+			continue
+		}
+		if ids != nil && !contains(ids, c.id) {
+			continue
+		}
+		source, err := json.Marshal(c.concept)
+		if err != nil {
+			continue
+		}
+		raw := json.RawMessage(source)
+		score := 1.0
+		sortValues := []interface{}{c.concept.PrefLabel, c.concept.Id}
+		if byPopularity {
+			annotationsCount := 0.0
+			if c.concept.Metrics != nil {
+				annotationsCount = float64(c.concept.Metrics.AnnotationsCount)
+			}
+			sortValues = append([]interface{}{annotationsCount}, sortValues...)
+		}
+		hits = append(hits, &elastic.SearchHit{
+			Index:  index,
+			Type:   c.esType,
+			Id:     c.id,
+			Score:  &score,
+			Source: &raw,
+			Sort:   sortValues,
+		})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return lessSortValues(hits[i].Sort, hits[j].Sort)
+	})
+	return hits
+}
+
+func hitsAfter(hits []*elastic.SearchHit, sortValues []interface{}) []*elastic.SearchHit {
+	for i, hit := range hits {
+		if lessSortValues(sortValues, hit.Sort) {
+			return hits[i:]
+		}
+	}
+	return nil
+}
+
+// lessSortValues compares the sort values of two hits, the numbers (annotation counts) in descending order and the
+// strings in ascending order
+func lessSortValues(a []interface{}, b []interface{}) bool {
+	for i := range a {
+		if x, ok := a[i].(float64); ok {
+			y, _ := b[i].(float64)
+			if x != y {
+				return x > y
+			}
+			continue
+		}
+		x, _ := a[i].(string)
+		y, _ := b[i].(string)
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+func newMemorySearchResult(hits []*elastic.SearchHit, total int) *elastic.SearchResult {
+	maxScore := 1.0
+	return &elastic.SearchResult{
+		Hits: &elastic.SearchHits{
+			TotalHits: int64(total),
+			MaxScore:  &maxScore,
+			Hits:      hits,
+		},
+	}
+}
+
+// searchParams are the parts of a search body the in-memory repository takes into account
+type searchParams struct {
+	From        *int                     `json:"from"`
+	Size        *int                     `json:"size"`
+	SearchAfter []interface{}            `json:"search_after"`
+	Sort        []map[string]interface{} `json:"sort"`
+	Query       struct {
+		Ids *struct {
+			Values []string `json:"values"`
+		} `json:"ids"`
+	} `json:"query"`
+}
+
+func readSearchParams(source interface{}) (searchParams, error) {
+	params := searchParams{}
+	if searchSource, ok := source.(*elastic.SearchSource); ok {
+		if searchSource == nil {
+			return params, nil
+		}
+		src, err := searchSource.Source()
+		if err != nil {
+			return params, err
+		}
+		source = src
+	}
+
+	body, ok := source.(json.RawMessage)
+	if !ok {
+		var err error
+		if body, err = json.Marshal(source); err != nil {
+			return params, err
+		}
+	}
+	err := json.Unmarshal(body, &params)
+	return params, err
+}
+
+func (p searchParams) size() int {
+	if p.Size == nil {
+		return defaultSearchSize
+	}
+	return *p.Size
+}
+
+func (p searchParams) byPopularity() bool {
+	if len(p.Sort) == 0 {
+		return false
+	}
+	_, found := p.Sort[0]["metrics.annotationsCount"]
+	return found
+}
+
+func (p searchParams) ids() []string {
+	if p.Query.Ids == nil {
+		return nil
+	}
+	return p.Query.Ids.Values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package servicetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+const (
+	testIndex   = "test-index"
+	esGenreType = "genres"
+	esBrandType = "brands"
+)
+
+func newTestInMemoryRepository() *InMemoryConceptRepository {
+	return NewInMemoryConceptRepository().
+		Add(testIndex, esGenreType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/2a88a647-59bc-4043-8f1b-5add71ddb3a0", PrefLabel: "Analysis", DirectType: "http://www.ft.com/ontology/Genre"},
+			service.EsConceptModel{Id: "http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", PrefLabel: "Comment", DirectType: "http://www.ft.com/ontology/Genre"},
+		).
+		Add(testIndex, esBrandType,
+			service.EsConceptModel{Id: "http://api.ft.com/things/40f636a3-5507-4311-9629-95376007cb7b", PrefLabel: "Lex", DirectType: "http://www.ft.com/ontology/product/Brand"},
+		)
+}
+
+func TestInMemoryRepositorySearchByType(t *testing.T) {
+	repository := newTestInMemoryRepository()
+
+	result, err := repository.Search(context.Background(), service.SearchQuery{Index: testIndex, Types: []string{esGenreType}, Source: elastic.NewSearchSource()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.TotalHits())
+	assert.Equal(t, "2a88a647-59bc-4043-8f1b-5add71ddb3a0", result.Hits.Hits[0].Id)
+	assert.Equal(t, "61d707b5-6fab-3541-b017-49b72de80772", result.Hits.Hits[1].Id)
+
+	assert.Len(t, repository.Queries(), 1, "the query should be recorded")
+	assert.Equal(t, []string{esGenreType}, repository.Queries()[0].Types)
+}
+
+func TestInMemoryRepositoryCancelledContext(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repository.Search(ctx, service.SearchQuery{Index: testIndex, Source: elastic.NewSearchSource()})
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = repository.ClusterHealth(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestInMemoryRepositoryFailure(t *testing.T) {
+	expectedErr := errors.New("computer says no")
+	repository := newTestInMemoryRepository().FailWith(expectedErr)
+
+	_, err := repository.Search(context.Background(), service.SearchQuery{Index: testIndex, Source: elastic.NewSearchSource()})
+	assert.Equal(t, expectedErr, err)
+
+	_, err = repository.MultiSearch(context.Background(), testIndex, elastic.NewSearchRequest().Source(elastic.NewSearchSource()))
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestInMemoryRepositoryMultiSearch(t *testing.T) {
+	repository := newTestInMemoryRepository()
+
+	result, err := repository.MultiSearch(context.Background(), testIndex,
+		elastic.NewSearchRequest().Source(elastic.NewSearchSource().Query(elastic.NewIdsQuery("_all").Ids("40f636a3-5507-4311-9629-95376007cb7b"))),
+		elastic.NewSearchRequest().Source(elastic.NewSearchSource().Size(1)),
+	)
+	require.NoError(t, err)
+	require.Len(t, result.Responses, 2)
+	assert.Equal(t, "40f636a3-5507-4311-9629-95376007cb7b", result.Responses[0].Hits.Hits[0].Id)
+	assert.Len(t, result.Responses[1].Hits.Hits, 1)
+}
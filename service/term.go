@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/Financial-Times/concept-search-api/util"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/olivere/elastic.v5"
+)
+
+var (
+	errEmptyTermsParameter         = util.NewParameterError(util.ErrCodeMissingParameter, "bestMatchTerms", "no best match terms specified")
+	errFacetsWithBestMatchTerms    = util.NewParameterError(util.ErrCodeUnsupportedParameter, "include_facets", "facets are only supported for 'term' searches")
+	errFuzzinessWithBestMatchTerms = util.NewParameterError(util.ErrCodeUnsupportedParameter, "fuzziness", "fuzzy matching is only supported for 'term' searches")
+	errInvalidFilterTypeParameter  = util.NewParameterError(util.ErrCodeInvalidParameter, "filter", "invalid filter type")
+	errInvalidSearchResult         = errors.New("invalid search result from ElasticSearch")
+	termSearchClauses              = []string{"textMatch", "prefLabelExactMatch", "aliasesExactMatch"}
+	fuzzyTermSearchClauses         = append(append([]string{}, termSearchClauses...), "fuzzyMatch")
+	bestMatchSearchClauses         = []string{"bestMatch"}
+	boostedBestMatchSearchClauses  = []string{"bestMatch", "authors"}
+)
+
+// authorsBestMatchBoost is the boost of the FT authors in the best matching searches, as tuned for the typeahead searches
+const authorsBestMatchBoost = 1.8
+
+// TermSearchOptions holds the optional parameters of the searches by term and by best matching terms.
+// The concept types, boost and filter only apply to the best matching searches, the fuzziness and facets to the
+// searches by term. The fields restrict the fields of the concepts fetched from Elasticsearch, all of them when empty.
+type TermSearchOptions struct {
+	SearchAllAuthorities bool
+	IncludeDeprecated    bool
+	Authorities          []string
+	ConceptTypes         []string
+	Boost                string
+	Filter               string
+	Fuzziness            string
+	IncludeFacets        bool
+	Highlight            bool
+	Explain              bool
+	Fields               []string
+}
+
+// ScoredConcept is a concept found by a search by term, as stored in Elasticsearch, along with its score and (when
+// asked for) its highlight and the explanation of its score.
+type ScoredConcept struct {
+	EsConceptModel
+	Score       float64
+	Highlight   Highlight
+	Explanation *ScoreExplanation
+}
+
+// TermSearchResult holds the concepts found by a search by term, best matches first, along with their facets when
+// asked for.
+type TermSearchResult struct {
+	Concepts []ScoredConcept
+	Facets   Facets
+}
+
+// SearchConceptsByTerm finds the concepts whose prefLabel or aliases match the term, exact matches first.
+func (s *esConceptSearchService) SearchConceptsByTerm(ctx context.Context, term string, opts TermSearchOptions) (TermSearchResult, error) {
+	fuzziness, err := util.ResolveFuzziness(false, opts.Fuzziness)
+	if err != nil {
+		return TermSearchResult{}, err
+	}
+
+	// the scoring clauses are named, so that the explanation of the scores can be broken down by clause
+	textMatch := elastic.NewMultiMatchQuery(term, "prefLabel", "aliases").Type("most_fields").QueryName("textMatch")
+	prefLabelExactMatch := elastic.NewTermQuery("prefLabel.raw", term).Boost(2).QueryName("prefLabelExactMatch")
+	aliasesExactMatch := elastic.NewTermQuery("aliases.raw", term).Boost(2).QueryName("aliasesExactMatch")
+
+	query := elastic.NewBoolQuery().Should(textMatch, prefLabelExactMatch, aliasesExactMatch)
+	clauses := termSearchClauses
+
+	// typo tolerant matches, boosted below the other matches so that these still come first
+	if fuzziness != "" {
+		fuzzyMatch := elastic.NewMultiMatchQuery(term, "prefLabel", "aliases").Type("most_fields").Fuzziness(fuzziness).PrefixLength(1).Boost(0.5).QueryName("fuzzyMatch")
+		query = query.Should(fuzzyMatch)
+		clauses = fuzzyTermSearchClauses
+	}
+	addTermSearchFilters(query, opts)
+
+	source := elastic.NewSearchSource().Query(query).Size(s.maxSearchResults)
+	if opts.IncludeFacets {
+		source = addFacetAggregations(source)
+	}
+	source = decorateTermSearchSource(source, opts)
+
+	result, err := s.repository.Search(ctx, SearchQuery{Index: s.getIndexForAuthoritiesParam(opts.SearchAllAuthorities), Source: source})
+	if err != nil {
+		log.Errorf("error: %v", err)
+		return TermSearchResult{}, err
+	}
+	if result.Hits == nil {
+		return TermSearchResult{}, errInvalidSearchResult
+	}
+
+	searchResult := TermSearchResult{Concepts: scoredConcepts(result, opts, clauses)}
+	if opts.IncludeFacets {
+		searchResult.Facets = FacetsFromResult(result)
+	}
+	return searchResult, nil
+}
+
+// FindBestMatchingConcepts finds the concept whose aliases best match each of the terms, which is nil for the terms
+// matching no concept. The FT authors are boosted with the authors boost, or are the only ones matched with the
+// authors filter, both of which require the concept types to be people.
+func (s *esConceptSearchService) FindBestMatchingConcepts(ctx context.Context, terms []string, opts TermSearchOptions) (map[string]*ScoredConcept, error) {
+	if len(terms) == 0 {
+		return nil, errEmptyTermsParameter
+	}
+	if opts.IncludeFacets {
+		return nil, errFacetsWithBestMatchTerms
+	}
+	if opts.Fuzziness != "" {
+		return nil, errFuzzinessWithBestMatchTerms
+	}
+
+	clauses := bestMatchSearchClauses
+	if opts.Boost != "" {
+		clauses = boostedBestMatchSearchClauses
+	}
+	requests := []*elastic.SearchRequest{}
+	for _, term := range terms {
+		source, err := bestMatchSearchSource(term, opts, s.maxSearchResults)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, elastic.NewSearchRequest().Source(source))
+	}
+
+	result, err := s.repository.MultiSearch(ctx, s.getIndexForAuthoritiesParam(opts.SearchAllAuthorities), requests...)
+	if err != nil {
+		log.Errorf("error: %v", err)
+		return nil, err
+	}
+	if len(result.Responses) != len(terms) {
+		return nil, errInvalidSearchResult
+	}
+
+	matches := make(map[string]*ScoredConcept)
+	for i, term := range terms {
+		matches[term] = nil
+		if result.Responses[i] == nil || result.Responses[i].Hits == nil {
+			continue
+		}
+		if concepts := scoredConcepts(result.Responses[i], opts, clauses); len(concepts) > 0 {
+			matches[term] = &concepts[0]
+		}
+	}
+	return matches, nil
+}
+
+// bestMatchSearchSource builds the query of the concepts whose aliases contain every word of the term
+func bestMatchSearchSource(term string, opts TermSearchOptions, size int) (*elastic.SearchSource, error) {
+	query := elastic.NewBoolQuery().Must(elastic.NewMatchQuery("aliases", term).Operator("and").QueryName("bestMatch"))
+
+	if opts.Boost != "" {
+		if err := validateAuthorsOption(opts.Boost, opts.ConceptTypes, util.ErrInvalidBoostTypeParameter); err != nil {
+			return nil, err
+		}
+		query = query.Should(elastic.NewTermQuery("isFTAuthor", "true").Boost(authorsBestMatchBoost).QueryName("authors"))
+	}
+	if opts.Filter != "" {
+		if err := validateAuthorsOption(opts.Filter, opts.ConceptTypes, errInvalidFilterTypeParameter); err != nil {
+			return nil, err
+		}
+		query = query.Filter(elastic.NewTermQuery("isFTAuthor", "true"))
+	}
+	if len(opts.ConceptTypes) > 0 {
+		typeFilter, err := ConceptTypesFilter(opts.ConceptTypes) // subtypes included
+		if err != nil {
+			return nil, err
+		}
+		query = query.Filter(typeFilter)
+	}
+	addTermSearchFilters(query, opts)
+
+	return decorateTermSearchSource(elastic.NewSearchSource().Size(size).Query(query), opts), nil
+}
+
+// validateAuthorsOption checks the boost or filter of a best matching search, authors being the only one supported
+func validateAuthorsOption(option string, conceptTypes []string, errInvalidOption error) error {
+	if option != "authors" {
+		return errInvalidOption
+	}
+	return util.ValidateForAuthorsSearch(conceptTypes, option)
+}
+
+func addTermSearchFilters(query *elastic.BoolQuery, opts TermSearchOptions) {
+	if !opts.IncludeDeprecated {
+		query.MustNot(elastic.NewTermQuery("isDeprecated", true))
+	}
+	if len(opts.Authorities) > 0 {
+		query.Filter(authoritiesFilter(opts.Authorities))
+	}
+}
+
+func decorateTermSearchSource(source *elastic.SearchSource, opts TermSearchOptions) *elastic.SearchSource {
+	source = fetchFields(source, opts.Fields)
+	if opts.Highlight {
+		source = source.Highlight(NewHighlight())
+	}
+	if opts.Explain {
+		source = source.Explain(true)
+	}
+	return source
+}
+
+// scoredConcepts reads the concepts out of the hits of a search by term, whose scoring clauses have the given names
+func scoredConcepts(result *elastic.SearchResult, opts TermSearchOptions, clauses []string) []ScoredConcept {
+	concepts := []ScoredConcept{}
+	for _, hit := range result.Hits.Hits {
+		if hit.Source == nil {
+			continue
+		}
+		concept := ScoredConcept{}
+		if err := json.Unmarshal(*hit.Source, &concept.EsConceptModel); err != nil {
+			log.Warnf("unmarshallable response from ElasticSearch: %v", err)
+			continue
+		}
+		if hit.Score != nil {
+			concept.Score = *hit.Score
+		}
+		if opts.Highlight {
+			concept.Highlight = HighlightFromHit(hit)
+		}
+		if opts.Explain {
+			concept.Explanation = ExplainHit(hit, clauses)
+		}
+		concepts = append(concepts, concept)
+	}
+	return concepts
+}
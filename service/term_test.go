@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/concept-search-api/service"
+	"github.com/Financial-Times/concept-search-api/service/servicetest"
+	"github.com/Financial-Times/concept-search-api/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchConceptsByTerm(t *testing.T) {
+	repository := newTestInMemoryRepository()
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, testExtendedIndex, 10, 10)
+
+	result, err := svc.SearchConceptsByTerm(context.Background(), "Lex", service.TermSearchOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Concepts)
+	assert.Nil(t, result.Facets, "facets should only be returned when asked for")
+
+	_, err = svc.SearchConceptsByTerm(context.Background(), "Lex", service.TermSearchOptions{SearchAllAuthorities: true, IncludeDeprecated: true, Fuzziness: "1", Authorities: []string{"TME"}})
+	require.NoError(t, err)
+
+	queries := repository.Queries()
+	require.Len(t, queries, 2)
+	assert.Equal(t, testDefaultIndex, queries[0].Index)
+	query := queryJSON(t, queries[0])
+	for _, name := range service.TermSearchClauses {
+		assert.Contains(t, query, `"_name":"`+name+`"`)
+	}
+	assert.NotContains(t, query, `"_name":"fuzzyMatch"`, "fuzzy matching should only be done when asked for")
+	assert.Contains(t, query, `"must_not":{"term":{"isDeprecated":true}}`, "the deprecated concepts should be filtered out")
+
+	assert.Equal(t, testExtendedIndex, queries[1].Index)
+	query = queryJSON(t, queries[1])
+	assert.Contains(t, query, `"_name":"fuzzyMatch"`)
+	assert.Contains(t, query, `"fuzziness":"1"`)
+	assert.Contains(t, query, `{"terms":{"authorities":["TME"]}}`, "the query should be filtered by authorities")
+	assert.NotContains(t, query, `"must_not"`)
+}
+
+func TestSearchConceptsByTermWithInvalidFuzziness(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestInMemoryRepository(), testDefaultIndex, testExtendedIndex, 10, 10)
+
+	_, err := svc.SearchConceptsByTerm(context.Background(), "Lex", service.TermSearchOptions{Fuzziness: "3"})
+	assert.Equal(t, util.ErrInvalidFuzzinessParameter, err)
+}
+
+func TestFindBestMatchingConcepts(t *testing.T) {
+	repository := servicetest.NewInMemoryConceptRepository().Add(testDefaultIndex, esPeopleType, service.EsConceptModel{Id: "http://api.ft.com/things/f758ef56-c40a-3162-91aa-3e8a3aabc494", PrefLabel: "Adam Samson"})
+	svc := service.NewConceptSearchService(repository, testDefaultIndex, testExtendedIndex, 10, 10)
+
+	matches, err := svc.FindBestMatchingConcepts(context.Background(), []string{"Adam Samson", "Eric Platt"}, service.TermSearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	require.NotNil(t, matches["Adam Samson"])
+	assert.Equal(t, "Adam Samson", matches["Adam Samson"].PrefLabel)
+}
+
+func TestFindBestMatchingConceptsUnsupportedOptions(t *testing.T) {
+	svc := service.NewConceptSearchService(newTestInMemoryRepository(), testDefaultIndex, testExtendedIndex, 10, 10)
+
+	testCases := []struct {
+		name     string
+		terms    []string
+		opts     service.TermSearchOptions
+		expected error
+	}{
+		{"no terms", nil, service.TermSearchOptions{}, service.ErrEmptyTermsParameter},
+		{"facets", []string{"Foobar"}, service.TermSearchOptions{IncludeFacets: true}, service.ErrFacetsWithBestMatchTerms},
+		{"fuzziness", []string{"Foobar"}, service.TermSearchOptions{Fuzziness: "1"}, service.ErrFuzzinessWithBestMatchTerms},
+		{"invalid boost", []string{"Foobar"}, service.TermSearchOptions{Boost: "wrong_boost"}, util.ErrInvalidBoostTypeParameter},
+		{"invalid filter", []string{"Foobar"}, service.TermSearchOptions{Filter: "wrong_filter"}, service.ErrInvalidFilterTypeParameter},
+		{"boost without people", []string{"Foobar"}, service.TermSearchOptions{Boost: "authors"}, util.ErrNoConceptTypeParameter},
+	}
+
+	for _, tc := range testCases {
+		_, err := svc.FindBestMatchingConcepts(context.Background(), tc.terms, tc.opts)
+		assert.Equal(t, tc.expected, err, tc.name)
+	}
+}
+
+func TestBestMatchSearchSourceWithExplain(t *testing.T) {
+	source, err := service.BestMatchSearchSource("Foobar", service.TermSearchOptions{Boost: "authors", ConceptTypes: []string{ftPeopleType}, Explain: true}, 10)
+	require.NoError(t, err)
+
+	query := queryJSON(t, service.SearchQuery{Source: source})
+	assert.Contains(t, query, `"explain":true`)
+	for _, name := range service.BoostedBestMatchSearchClauses {
+		assert.Contains(t, query, `"_name":"`+name+`"`)
+	}
+}
+
+// during concept deprecation story an issue was encountered during calling FindConcept.
+// The filtering was applied in a way that the data was returned even when the query did not match the doc.
+func TestBestMatchSearchSourceWithAuthorities(t *testing.T) {
+	source, err := service.BestMatchSearchSource("Platt Eric", service.TermSearchOptions{Authorities: []string{"TME", "Smartlogic"}}, 10)
+	require.NoError(t, err)
+
+	query := queryJSON(t, service.SearchQuery{Source: source})
+	assert.Contains(t, query, `"must":{"match":{"aliases":`, "the query should match the term")
+	assert.Contains(t, query, `{"terms":{"authorities":["TME","Smartlogic"]}}`, "the query should be filtered by authorities")
+}
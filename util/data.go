@@ -3,10 +3,8 @@ package util
 import (
 	"errors"
 	"fmt"
-)
-
-const (
-	PublicCompany = "http://www.ft.com/ontology/company/PublicCompany"
+	"regexp"
+	"strings"
 )
 
 var (
@@ -18,13 +16,26 @@ var (
 		"http://www.ft.com/ontology/Location":                  "locations",
 		"http://www.ft.com/ontology/Topic":                     "topics",
 		"http://www.ft.com/ontology/AlphavilleSeries":          "alphaville-series",
+		"http://www.ft.com/ontology/Section":                   "sections",
+		"http://www.ft.com/ontology/Subject":                   "subjects",
 	}
 
 	ErrInvalidConceptTypeFormat              = "invalid concept type %v"
+	ErrInvalidConceptIdsFormat               = "invalid concept ids %v (they should be UUIDs or FT concept URIs)"
 	ErrNoElasticClient                       = errors.New("no ElasticSearch client available")
-	ErrNoConceptTypeParameter                = NewInputError("no concept type specified")
-	ErrNotSupportedCombinationOfConceptTypes = NewInputError("the combination of concept types is not supported")
-	ErrInvalidBoostTypeParameter             = NewInputError("invalid boost type")
+	ErrConceptNotFound                       = errors.New("concept not found")
+	ErrQueryTimeout                          = errors.New("the ElasticSearch query timed out")
+	ErrNoConceptTypeParameter                = NewParameterError(ErrCodeMissingParameter, "type", "no concept type specified")
+	ErrNotSupportedCombinationOfConceptTypes = NewParameterError(ErrCodeInvalidParameter, "type", "the combination of concept types is not supported")
+	ErrInvalidBoostTypeParameter             = NewParameterError(ErrCodeInvalidParameter, "boost", "invalid boost type")
+	ErrInvalidFuzzinessParameter             = NewParameterError(ErrCodeInvalidParameter, "fuzziness", "invalid fuzziness, it should be one of AUTO, 1 or 2")
+
+	fuzzinessValues = []string{"AUTO", "1", "2"}
+
+	uuidPattern = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+	// conceptIdRegex matches the bare UUIDs and the FT URIs of the concepts, i.e. their ids (http://www.ft.com/thing/),
+	// their generic API URLs (http://api.ft.com/things/ or concepts/) and their API URLs by type (e.g. http://api.ft.com/people/)
+	conceptIdRegex = regexp.MustCompile(`^(?:https?://(?:www\.ft\.com/thing|api\.ft\.com/(?:things|concepts|` + strings.Join(esTypes(), "|") + `))/)?(` + uuidPattern + `)$`)
 )
 
 func FirstError(errors ...error) error {
@@ -59,6 +70,46 @@ func FtType(esType string) string {
 	return ""
 }
 
+func esTypes() []string {
+	types := []string{}
+	for _, esType := range esTypeMapping {
+		types = append(types, regexp.QuoteMeta(esType))
+	}
+	return types
+}
+
+// ConceptUUID extracts the UUID of a concept from its id, which is either the UUID itself or one of its FT URIs.
+// The UUID is lower-cased, as concepts are stored under their lower-case UUID.
+func ConceptUUID(id string) (string, bool) {
+	match := conceptIdRegex.FindStringSubmatch(strings.TrimSpace(id))
+	if match == nil {
+		return "", false
+	}
+	return strings.ToLower(match[1]), true
+}
+
+// ConceptUUIDs extracts the UUIDs of the concepts from their ids (see ConceptUUID), failing with an InputError which
+// lists every malformed id. Empty ids are left empty.
+func ConceptUUIDs(ids []string) ([]string, error) {
+	uuids := make([]string, len(ids))
+	malformed := []string{}
+	for i, id := range ids {
+		if id == "" {
+			continue
+		}
+		uuid, ok := ConceptUUID(id)
+		if !ok {
+			malformed = append(malformed, fmt.Sprintf("'%s'", id))
+			continue
+		}
+		uuids[i] = uuid
+	}
+	if len(malformed) > 0 {
+		return nil, NewParameterErrorf(ErrCodeInvalidParameter, "ids", ErrInvalidConceptIdsFormat, strings.Join(malformed, ", "))
+	}
+	return uuids, nil
+}
+
 func ValidateForAuthorsSearch(conceptTypes []string, boostType string) error {
 	if len(conceptTypes) == 0 {
 		return ErrNoConceptTypeParameter
@@ -67,7 +118,7 @@ func ValidateForAuthorsSearch(conceptTypes []string, boostType string) error {
 		return ErrNotSupportedCombinationOfConceptTypes
 	}
 	if EsType(conceptTypes[0]) != "people" {
-		return NewInputErrorf(ErrInvalidConceptTypeFormat, conceptTypes[0])
+		return NewParameterErrorf(ErrCodeInvalidParameter, "type", ErrInvalidConceptTypeFormat, conceptTypes[0])
 	}
 	if boostType != "authors" {
 		return ErrInvalidBoostTypeParameter
@@ -75,36 +126,69 @@ func ValidateForAuthorsSearch(conceptTypes []string, boostType string) error {
 	return nil
 }
 
-func ValidateAndConvertToEsTypes(conceptTypes []string) ([]string, bool, error) {
-	esTypes := make([]string, len(conceptTypes))
-	isPublicCompany := false
-
-	for _, t := range conceptTypes {
-		if t == PublicCompany {
-			isPublicCompany = true
-			continue
+// ResolveFuzziness works out the ES fuzziness to be used by a search, given the fuzzy flag and the explicit fuzziness
+// requested (if any). An explicit fuzziness takes precedence over the flag, which stands for AUTO; an empty result
+// means no fuzzy matching at all.
+func ResolveFuzziness(fuzzy bool, fuzziness string) (string, error) {
+	if fuzziness == "" {
+		if fuzzy {
+			return "AUTO", nil
 		}
-		esT := EsType(t)
-		if esT == "" {
-			return esTypes, false, NewInputErrorf(ErrInvalidConceptTypeFormat, t)
+		return "", nil
+	}
+	for _, v := range fuzzinessValues {
+		if fuzziness == v {
+			return fuzziness, nil
 		}
-		esTypes = append(esTypes, esT)
 	}
-	return esTypes, isPublicCompany, nil
+	return "", ErrInvalidFuzzinessParameter
 }
 
+// InputError is an error caused by the input of a request, which is answered with a 400 Bad Request.
+// It tells its code to the clients (see the ErrCode constants), along with the parameter at fault if any.
 type InputError struct {
-	msg string
+	msg   string
+	code  string
+	param string
 }
 
 func (e InputError) Error() string {
 	return e.msg
 }
 
+// Code returns the code of the error, ErrCodeInvalidRequest unless given another one
+func (e InputError) Code() string {
+	if e.code == "" {
+		return ErrCodeInvalidRequest
+	}
+	return e.code
+}
+
+// Parameter returns the name of the parameter at fault, which is empty when the request as a whole is invalid
+func (e InputError) Parameter() string {
+	return e.param
+}
+
+// ForParameter returns a copy of the error whose parameter at fault is the given one
+func (e InputError) ForParameter(param string) InputError {
+	e.param = param
+	return e
+}
+
 func NewInputError(msg string) InputError {
-	return InputError{msg}
+	return InputError{msg: msg}
 }
 
 func NewInputErrorf(format string, args ...interface{}) InputError {
-	return InputError{fmt.Sprintf(format, args...)}
+	return InputError{msg: fmt.Sprintf(format, args...)}
+}
+
+// NewParameterError returns an InputError of the given code, caused by the given parameter
+func NewParameterError(code string, param string, msg string) InputError {
+	return InputError{msg: msg, code: code, param: param}
+}
+
+// NewParameterErrorf returns an InputError of the given code, caused by the given parameter
+func NewParameterErrorf(code string, param string, format string, args ...interface{}) InputError {
+	return InputError{msg: fmt.Sprintf(format, args...), code: code, param: param}
 }
@@ -1,7 +1,6 @@
 package util
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +11,13 @@ func TestEsType(t *testing.T) {
 	assert.Equal(t, "", EsType("http://www.ft.com/ontology/Foo"), "unknown type conversion")
 }
 
+func TestEsTypeSectionsAndSubjects(t *testing.T) {
+	assert.Equal(t, "sections", EsType("http://www.ft.com/ontology/Section"), "section type conversion")
+	assert.Equal(t, "subjects", EsType("http://www.ft.com/ontology/Subject"), "subject type conversion")
+	assert.Equal(t, "http://www.ft.com/ontology/Section", FtType("sections"), "section type conversion")
+	assert.Equal(t, "http://www.ft.com/ontology/Subject", FtType("subjects"), "subject type conversion")
+}
+
 func TestFtType(t *testing.T) {
 	assert.Equal(t, "http://www.ft.com/ontology/Genre", FtType("genres"), "known type conversion")
 	assert.Equal(t, "", FtType("tardigrades"), "unknown type conversion")
@@ -34,28 +40,90 @@ func TestValidateAuthors(t *testing.T) {
 	assert.Nil(t, ValidateForAuthorsSearch([]string{"http://www.ft.com/ontology/person/Person"}, "authors"))
 }
 
-func TestValidateEsTypesNoError(t *testing.T) {
-	res, isPublicCompany, err := ValidateAndConvertToEsTypes([]string{"http://www.ft.com/ontology/person/Person"})
-	assert.NoError(t, err)
-	assert.Len(t, res, 2)
-	assert.Equal(t, "", res[0])
-	assert.Equal(t, "people", res[1])
-	assert.Equal(t, false, isPublicCompany)
+func TestResolveFuzziness(t *testing.T) {
+	testCases := []struct {
+		fuzzy             bool
+		fuzziness         string
+		expectedFuzziness string
+		expectedErr       error
+	}{
+		{false, "", "", nil},
+		{true, "", "AUTO", nil},
+		{false, "AUTO", "AUTO", nil},
+		{false, "1", "1", nil},
+		{true, "2", "2", nil},
+		{true, "3", "", ErrInvalidFuzzinessParameter},
+		{false, "auto", "", ErrInvalidFuzzinessParameter},
+	}
+
+	for _, tc := range testCases {
+		fuzziness, err := ResolveFuzziness(tc.fuzzy, tc.fuzziness)
+		assert.Equal(t, tc.expectedFuzziness, fuzziness, "fuzzy=%v fuzziness=%v", tc.fuzzy, tc.fuzziness)
+		assert.Equal(t, tc.expectedErr, err, "fuzzy=%v fuzziness=%v", tc.fuzzy, tc.fuzziness)
+	}
 }
 
-func TestValidateEsTypesReturnError(t *testing.T) {
-	_, isPublicCompany, err := ValidateAndConvertToEsTypes([]string{"http://www.ft.com/ontology/Foo", "http://www.ft.com/ontology/person/Person"})
-	assert.Contains(t, err.Error(), "http://www.ft.com/ontology/Foo")
-	assert.Equal(t, false, isPublicCompany)
+func TestConceptUUID(t *testing.T) {
+	testCases := []struct {
+		id           string
+		expectedUUID string
+		expectedOk   bool
+	}{
+		{"61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"61D707B5-6FAB-3541-B017-49B72DE80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{" 61d707b5-6fab-3541-b017-49b72de80772 ", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"http://www.ft.com/thing/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"https://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"http://api.ft.com/concepts/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"http://api.ft.com/people/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"http://api.ft.com/organisations/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"http://api.ft.com/alphaville-series/61d707b5-6fab-3541-b017-49b72de80772", "61d707b5-6fab-3541-b017-49b72de80772", true},
+		{"", "", false},
+		{"uuid1", "", false},
+		{"61d707b5-6fab-3541-b017-49b72de8077", "", false},
+		{"61d707b56fab3541b01749b72de80772", "", false},
+		{"61d707b5-6fab-3541-b017-49b72de80772/", "", false},
+		{"http://www.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772", "", false},
+		{"http://api.ft.com/tardigrades/61d707b5-6fab-3541-b017-49b72de80772", "", false},
+		{"http://www.example.com/thing/61d707b5-6fab-3541-b017-49b72de80772", "", false},
+		{"http://api.ft.com/things/61d707b5-6fab-3541-b017-49b72de80772?foo=bar", "", false},
+	}
+
+	for _, tc := range testCases {
+		uuid, ok := ConceptUUID(tc.id)
+		assert.Equal(t, tc.expectedUUID, uuid, "id=%v", tc.id)
+		assert.Equal(t, tc.expectedOk, ok, "id=%v", tc.id)
+	}
 }
 
-func TestValidateEsTypesWithPublicCompany(t *testing.T) {
-	res, isPublicCompany, err := ValidateAndConvertToEsTypes([]string{"http://www.ft.com/ontology/person/Person", "http://www.ft.com/ontology/company/PublicCompany"})
-	fmt.Printf("%v", res)
-	assert.NoError(t, err)
-	assert.Len(t, res, 3)
-	assert.Equal(t, "", res[0])
-	assert.Equal(t, "", res[1])
-	assert.Equal(t, "people", res[2])
-	assert.Equal(t, true, isPublicCompany)
+func TestConceptUUIDs(t *testing.T) {
+	testCases := []struct {
+		ids           []string
+		expectedUUIDs []string
+		expectedErr   error
+	}{
+		{[]string{}, []string{}, nil},
+		{
+			[]string{"61d707b5-6fab-3541-b017-49b72de80772", "http://api.ft.com/people/40f636a3-5507-4311-9629-95376007cb7b"},
+			[]string{"61d707b5-6fab-3541-b017-49b72de80772", "40f636a3-5507-4311-9629-95376007cb7b"},
+			nil,
+		},
+		{
+			[]string{"", "61d707b5-6fab-3541-b017-49b72de80772"},
+			[]string{"", "61d707b5-6fab-3541-b017-49b72de80772"},
+			nil,
+		},
+		{
+			[]string{"61d707b5-6fab-3541-b017-49b72de80772", "xxx", "http://api.ft.com/things/zzzz"},
+			nil,
+			NewParameterError(ErrCodeInvalidParameter, "ids", "invalid concept ids 'xxx', 'http://api.ft.com/things/zzzz' (they should be UUIDs or FT concept URIs)"),
+		},
+	}
+
+	for _, tc := range testCases {
+		uuids, err := ConceptUUIDs(tc.ids)
+		assert.Equal(t, tc.expectedUUIDs, uuids, "ids=%v", tc.ids)
+		assert.Equal(t, tc.expectedErr, err, "ids=%v", tc.ids)
+	}
 }
@@ -1,15 +1,70 @@
 package util
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
+
+	"github.com/Financial-Times/transactionid-utils-go"
+)
+
+// The codes of the error responses, which the clients can rely upon.
+const (
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeInvalidBody          = "invalid_body"
+	ErrCodeInvalidParameter     = "invalid_parameter"
+	ErrCodeMissingParameter     = "missing_parameter"
+	ErrCodeUnsupportedParameter = "unsupported_parameter"
+	ErrCodeFeatureDisabled      = "feature_disabled"
+	ErrCodeNotFound             = "not_found"
+	ErrCodeSearchUnavailable    = "search_unavailable"
+	ErrCodeSearchTimeout        = "search_timeout"
+	ErrCodeSearchFailed         = "search_failed"
+	ErrCodeInternalError        = "internal_error"
 )
 
+// CodedError is an error which tells its code to the clients, along with the parameter at fault if any.
+type CodedError interface {
+	error
+	Code() string
+	Parameter() string
+}
+
+// ErrorResponse is the body of the error responses of every endpoint.
+type ErrorResponse struct {
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+	Parameter     string `json:"parameter,omitempty"`
+	TransactionID string `json:"transaction_id"`
+}
+
+// NewErrorResponse describes an error to the clients. A CodedError is given its own code and parameter, any other
+// error the given code.
+func NewErrorResponse(req *http.Request, code string, err error) ErrorResponse {
+	response := ErrorResponse{
+		Code:          code,
+		Message:       err.Error(),
+		TransactionID: transactionidutils.GetTransactionIDFromRequest(req),
+	}
+	if coded, ok := err.(CodedError); ok {
+		response.Code = coded.Code()
+		response.Parameter = coded.Parameter()
+	}
+	return response
+}
+
+// WriteError answers a request with the given status and the ErrorResponse of the error (see NewErrorResponse).
+func WriteError(w http.ResponseWriter, req *http.Request, status int, code string, err error) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(NewErrorResponse(req, code, err))
+}
+
 func GetSingleValueQueryParameter(req *http.Request, param string, allowed ...string) (string, bool, error) {
 	values, found := GetMultipleValueQueryParameter(req, param)
 	if len(values) > 1 {
-		return "", found, fmt.Errorf("specified multiple %v query parameters in the URL", param)
+		return "", found, NewParameterErrorf(ErrCodeInvalidParameter, param, "specified multiple %v query parameters in the URL", param)
 	}
 	if len(values) < 1 {
 		return "", found, nil
@@ -23,7 +78,7 @@ func GetSingleValueQueryParameter(req *http.Request, param string, allowed ...st
 			}
 		}
 
-		return "", found, fmt.Errorf("'%s' is not a valid value for parameter '%s'", v, param)
+		return "", found, NewParameterErrorf(ErrCodeInvalidParameter, param, "'%s' is not a valid value for parameter '%s'", v, param)
 	}
 
 	return v, found, nil
@@ -37,12 +92,36 @@ func GetBoolQueryParameter(req *http.Request, param string, defaultVal bool) (bo
 
 	boolVal, err := strconv.ParseBool(val)
 	if err != nil {
-		return defaultVal, false, err
+		return defaultVal, false, NewParameterError(ErrCodeInvalidParameter, param, err.Error())
 	}
 
 	return boolVal, true, nil
 }
 
+func GetIntQueryParameter(req *http.Request, param string, defaultVal int) (int, bool, error) {
+	val, found, err := GetSingleValueQueryParameter(req, param)
+	if !found || err != nil {
+		return defaultVal, found, err
+	}
+	intVal, err := strconv.Atoi(val)
+	if err != nil {
+		return defaultVal, false, NewParameterErrorf(ErrCodeInvalidParameter, param, "'%s' is not a valid value for parameter '%s'", val, param)
+	}
+	return intVal, true, nil
+}
+
+func GetTimeQueryParameter(req *http.Request, param string) (time.Time, bool, error) {
+	val, found, err := GetSingleValueQueryParameter(req, param)
+	if !found || err != nil {
+		return time.Time{}, found, err
+	}
+	timeVal, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, false, NewParameterErrorf(ErrCodeInvalidParameter, param, "'%s' is not a valid RFC3339 value for parameter '%s'", val, param)
+	}
+	return timeVal, true, nil
+}
+
 func GetMultipleValueQueryParameter(req *http.Request, param string) ([]string, bool) {
 	query := req.URL.Query()
 	values, found := query[param]
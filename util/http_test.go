@@ -2,7 +2,9 @@ package util
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -66,3 +68,64 @@ func TestGetBoolValueOkValue(t *testing.T) {
 	assert.True(t, found)
 	assert.NoError(t, err)
 }
+
+func TestGetIntValueNoParam(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath, nil)
+	value, found, err := GetIntQueryParameter(req, "test-param", 7)
+	assert.Equal(t, 7, value)
+	assert.False(t, found)
+	assert.NoError(t, err)
+}
+
+func TestGetIntValueNotIntValueGiven(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath+"?test-param=a", nil)
+	value, found, err := GetIntQueryParameter(req, "test-param", 7)
+	assert.Equal(t, 7, value)
+	assert.False(t, found)
+	assert.Equal(t, "'a' is not a valid value for parameter 'test-param'", err.Error())
+}
+
+func TestGetIntValueOkValue(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath+"?test-param=20", nil)
+	value, found, err := GetIntQueryParameter(req, "test-param", 7)
+	assert.Equal(t, 20, value)
+	assert.True(t, found)
+	assert.NoError(t, err)
+}
+
+func TestGetTimeValueNoParam(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath, nil)
+	value, found, err := GetTimeQueryParameter(req, "test-param")
+	assert.True(t, value.IsZero())
+	assert.False(t, found)
+	assert.NoError(t, err)
+}
+
+func TestGetTimeValueNotTimeValueGiven(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath+"?test-param=yesterday", nil)
+	value, found, err := GetTimeQueryParameter(req, "test-param")
+	assert.True(t, value.IsZero())
+	assert.False(t, found)
+	assert.Equal(t, "'yesterday' is not a valid RFC3339 value for parameter 'test-param'", err.Error())
+}
+
+func TestGetTimeValueOkValue(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath+"?test-param=2019-05-13T10:12:02%2B01:00", nil)
+	value, found, err := GetTimeQueryParameter(req, "test-param")
+	assert.True(t, value.Equal(time.Date(2019, 5, 13, 9, 12, 2, 0, time.UTC)))
+	assert.True(t, found)
+	assert.NoError(t, err)
+}
+
+func TestNewErrorResponse(t *testing.T) {
+	req, _ := http.NewRequest("GET", httpTestBasePath+"?size=lots", nil)
+	req.Header.Set("X-Request-Id", "tid_test")
+
+	_, _, err := GetIntQueryParameter(req, "size", 0)
+	assert.Equal(t, ErrorResponse{Code: ErrCodeInvalidParameter, Message: "'lots' is not a valid value for parameter 'size'", Parameter: "size", TransactionID: "tid_test"}, NewErrorResponse(req, ErrCodeInvalidRequest, err))
+	assert.Equal(t, ErrorResponse{Code: ErrCodeInvalidRequest, Message: "computer says no", TransactionID: "tid_test"}, NewErrorResponse(req, ErrCodeSearchFailed, NewInputError("computer says no")), "input errors should be invalid requests by default")
+	assert.Equal(t, ErrorResponse{Code: ErrCodeSearchTimeout, Message: ErrQueryTimeout.Error(), TransactionID: "tid_test"}, NewErrorResponse(req, ErrCodeSearchTimeout, ErrQueryTimeout))
+
+	response := NewErrorResponse(httptest.NewRequest("GET", httpTestBasePath, nil), ErrCodeSearchFailed, ErrQueryTimeout)
+	assert.NotEmpty(t, response.TransactionID, "a transaction id should be generated when the request has none")
+}
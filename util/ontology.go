@@ -0,0 +1,58 @@
+package util
+
+const (
+	Organisation   = "http://www.ft.com/ontology/organisation/Organisation"
+	Company        = "http://www.ft.com/ontology/company/Company"
+	PublicCompany  = "http://www.ft.com/ontology/company/PublicCompany"
+	PrivateCompany = "http://www.ft.com/ontology/company/PrivateCompany"
+)
+
+// parentTypes maps the subtypes of the FT ontology to their parent type. Subtypes have no ES type of their own, their
+// concepts are stored under the ES type of their closest ancestor having one, and hold all their types in `types`.
+var parentTypes = map[string]string{
+	Company:        Organisation,
+	PublicCompany:  Company,
+	PrivateCompany: Company,
+}
+
+// ConceptTypeFilter tells how to pick the concepts of a type out of an index: by the ES type they are stored under
+// and, for the subtypes, by the subtype itself, which has to be one of the indexed `types` of the concepts.
+type ConceptTypeFilter struct {
+	EsType  string
+	Subtype string
+}
+
+// ParentType returns the parent of the given type, or an empty string for the types at the top of the ontology
+// (and for the unknown ones).
+func ParentType(ftType string) string {
+	return parentTypes[ftType]
+}
+
+// NewConceptTypeFilter works out how to filter the concepts of the given type, which is an InputError for the
+// types which are not part of the ontology.
+func NewConceptTypeFilter(ftType string) (ConceptTypeFilter, error) {
+	for t := ftType; t != ""; t = ParentType(t) {
+		if esType := EsType(t); esType != "" {
+			filter := ConceptTypeFilter{EsType: esType}
+			if t != ftType {
+				filter.Subtype = ftType
+			}
+			return filter, nil
+		}
+	}
+	return ConceptTypeFilter{}, NewParameterErrorf(ErrCodeInvalidParameter, "type", ErrInvalidConceptTypeFormat, ftType)
+}
+
+// NewConceptTypeFilters works out how to filter the concepts of any of the given types, failing on the first
+// type which is not part of the ontology.
+func NewConceptTypeFilters(ftTypes []string) ([]ConceptTypeFilter, error) {
+	filters := []ConceptTypeFilter{}
+	for _, t := range ftTypes {
+		filter, err := NewConceptTypeFilter(t)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
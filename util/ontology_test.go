@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConceptTypeFilter(t *testing.T) {
+	testCases := []struct {
+		ftType         string
+		expectedFilter ConceptTypeFilter
+		expectedErr    error
+	}{
+		{"http://www.ft.com/ontology/person/Person", ConceptTypeFilter{EsType: "people"}, nil},
+		{"http://www.ft.com/ontology/Section", ConceptTypeFilter{EsType: "sections"}, nil},
+		{Organisation, ConceptTypeFilter{EsType: "organisations"}, nil},
+		{Company, ConceptTypeFilter{EsType: "organisations", Subtype: Company}, nil},
+		{PublicCompany, ConceptTypeFilter{EsType: "organisations", Subtype: PublicCompany}, nil},
+		{PrivateCompany, ConceptTypeFilter{EsType: "organisations", Subtype: PrivateCompany}, nil},
+		{"http://www.ft.com/ontology/Foo", ConceptTypeFilter{}, NewParameterError(ErrCodeInvalidParameter, "type", "invalid concept type http://www.ft.com/ontology/Foo")},
+		{"", ConceptTypeFilter{}, NewParameterError(ErrCodeInvalidParameter, "type", "invalid concept type ")},
+	}
+
+	for _, tc := range testCases {
+		filter, err := NewConceptTypeFilter(tc.ftType)
+		assert.Equal(t, tc.expectedFilter, filter, "filter for %v", tc.ftType)
+		assert.Equal(t, tc.expectedErr, err, "error for %v", tc.ftType)
+	}
+}
+
+func TestNewConceptTypeFilters(t *testing.T) {
+	filters, err := NewConceptTypeFilters([]string{"http://www.ft.com/ontology/person/Person", PublicCompany})
+	assert.NoError(t, err)
+	assert.Equal(t, []ConceptTypeFilter{{EsType: "people"}, {EsType: "organisations", Subtype: PublicCompany}}, filters)
+
+	_, err = NewConceptTypeFilters([]string{"http://www.ft.com/ontology/Foo", "http://www.ft.com/ontology/person/Person"})
+	assert.Contains(t, err.Error(), "http://www.ft.com/ontology/Foo")
+}